@@ -81,3 +81,20 @@ func New(backend models.KV, opts ...client.Options) (client.Client, error) {
 
 	return client.New(backend, opt)
 }
+
+// Typed is a view of a [client.Client] whose values are all of type T. See [client.Typed].
+type Typed[T any] = client.Typed[T]
+
+// NewTyped returns a view of the client whose values are all of type T.
+//
+// Example:
+//
+//	c, err := kivigo.New(backend)
+//	if err != nil {
+//	    panic(err)
+//	}
+//	users := kivigo.NewTyped[User](c)
+//	user, err := users.Get(ctx, "user:42")
+func NewTyped[T any](c client.Client) Typed[T] {
+	return client.NewTyped[T](c)
+}
@@ -0,0 +1,978 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+var (
+	_ models.KV              = (*Cache)(nil)
+	_ models.KVWithBatch     = (*Cache)(nil)
+	_ models.KVWithHealth    = (*Cache)(nil)
+	_ models.KVWithKeyLimits = (*Cache)(nil)
+	_ models.KVWithTTL       = (*Cache)(nil)
+	_ models.KVWithCAS       = (*Cache)(nil)
+	_ models.KVWithTxn       = (*Cache)(nil)
+	_ models.KVWithScan      = (*Cache)(nil)
+	_ models.KVWithWatch     = (*Cache)(nil)
+)
+
+// defaultFlushInterval is the interval at which pending writes are flushed in write-behind mode
+// when Option.FlushInterval is zero.
+const defaultFlushInterval = time.Second
+
+// now returns the current time used to evaluate entry expirations.
+var now = time.Now
+
+type (
+	// Option configures a Cache.
+	Option struct {
+		// MaxEntries is the maximum number of cached entries.
+		// If zero, the number of entries is not limited.
+		MaxEntries int
+
+		// MaxBytes is the maximum total size of cached keys and values.
+		// If zero, the size is not limited.
+		MaxBytes int64
+
+		// TTL is the time after which a cached entry is refreshed from the backend.
+		// If zero, entries are kept until evicted or invalidated.
+		TTL time.Duration
+
+		// NegativeTTL is the time during which a missing key is remembered, avoiding repeated lookups.
+		// If zero, missing keys are not cached.
+		NegativeTTL time.Duration
+
+		// WriteBehind buffers writes and flushes them to the backend periodically.
+		// Successive writes to the same key are coalesced into a single backend write.
+		// Pending writes are lost if the process stops before they are flushed.
+		WriteBehind bool
+
+		// FlushInterval is the interval at which pending writes are flushed in write-behind mode.
+		// Default: 1 second
+		FlushInterval time.Duration
+
+		// OnFlushError is called when pending writes fail to be flushed in the background.
+		// Failed writes are retried on the next flush.
+		OnFlushError func(err error)
+	}
+
+	// Stats holds the counters of a Cache.
+	Stats struct {
+		Hits          uint64
+		Misses        uint64
+		NegativeHits  uint64
+		Evictions     uint64
+		Entries       int
+		Bytes         int64
+		PendingWrites int
+	}
+
+	// Cache is an in-process LRU cache wrapping a models.KV backend.
+	//
+	// Reads are served from memory when possible and writes go through to the backend,
+	// or are buffered in write-behind mode.
+	//
+	// The other capabilities of the backend, such as expirations, compare-and-swap, transactions, scans and watches,
+	// are forwarded to it after flushing the pending writes, and the keys they write are refreshed or invalidated.
+	// They return errs.ErrOperationNotSupported if the backend does not implement them, in which case clients
+	// fall back to their client-side implementations. Values read from the backend are cached regardless of their
+	// expiration in the backend, so Option.TTL bounds how long an expired value may still be served.
+	Cache struct {
+		kv   models.KV
+		opts Option
+
+		mu      sync.Mutex
+		lru     *list.List
+		items   map[string]*list.Element
+		bytes   int64
+		stats   Stats
+		pending map[string]pendingWrite
+
+		// flushing are the pending writes being flushed, still served to readers until they reach the backend.
+		flushing map[string]pendingWrite
+
+		// fetches track the keys being read from the backend, so that a value written or invalidated
+		// during the read is not overwritten by the value read.
+		fetches map[string]*fetch
+
+		flushMu   sync.Mutex
+		stop      chan struct{}
+		done      chan struct{}
+		closeOnce sync.Once
+		closeErr  error
+	}
+
+	// fetch tracks the reads of a key from the backend.
+	// Its generation is incremented whenever the key is written or invalidated.
+	fetch struct {
+		generation uint64
+		readers    int
+	}
+
+	// entry is a cached value. A nil value with missing set caches a errs.ErrNotFound.
+	entry struct {
+		key       string
+		value     []byte
+		missing   bool
+		expiresAt time.Time
+	}
+
+	// pendingWrite is a write waiting to be flushed in write-behind mode.
+	pendingWrite struct {
+		value   []byte
+		deleted bool
+	}
+)
+
+// New creates a new Cache wrapping the given backend.
+//
+// Example:
+//
+//	c := cache.New(backend, cache.Option{MaxEntries: 10000, TTL: time.Minute, NegativeTTL: 5 * time.Second})
+//	defer c.Close()
+//	client, err := kivigo.New(c)
+func New(kv models.KV, opts Option) *Cache {
+	c := &Cache{
+		kv:      kv,
+		opts:    opts,
+		lru:     list.New(),
+		items:   make(map[string]*list.Element),
+		pending: make(map[string]pendingWrite),
+		fetches: make(map[string]*fetch),
+	}
+
+	if opts.WriteBehind {
+		if c.opts.FlushInterval <= 0 {
+			c.opts.FlushInterval = defaultFlushInterval
+		}
+
+		c.stop = make(chan struct{})
+		c.done = make(chan struct{})
+
+		go c.flushLoop()
+	}
+
+	return c
+}
+
+// GetRaw returns the cached value of the key, or retrieves it from the backend on a miss.
+func (c *Cache) GetRaw(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	if p, ok := c.pendingWrite(key); ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+
+		if p.deleted {
+			return nil, errs.ErrNotFound
+		}
+		return p.value, nil
+	}
+
+	if e, ok := c.lookup(key); ok {
+		if e.missing {
+			c.stats.NegativeHits++
+			c.mu.Unlock()
+			return nil, errs.ErrNotFound
+		}
+
+		c.stats.Hits++
+		c.mu.Unlock()
+		return e.value, nil
+	}
+
+	c.stats.Misses++
+	generation := c.beginFetch(key)
+	c.mu.Unlock()
+
+	value, err := c.kv.GetRaw(ctx, key)
+	switch {
+	case err == nil:
+		c.fill(key, generation, value, false)
+	case errors.Is(err, errs.ErrNotFound) && c.opts.NegativeTTL > 0:
+		c.fill(key, generation, nil, true)
+	default:
+		c.mu.Lock()
+		c.endFetch(key, generation)
+		c.mu.Unlock()
+	}
+
+	return value, err
+}
+
+// SetRaw writes the value to the backend, or buffers it in write-behind mode, and caches it.
+func (c *Cache) SetRaw(ctx context.Context, key string, value []byte) error {
+	if c.opts.WriteBehind {
+		c.mu.Lock()
+		c.pending[key] = pendingWrite{value: value}
+		c.mu.Unlock()
+
+		c.store(key, value, false)
+
+		return nil
+	}
+
+	if err := c.kv.SetRaw(ctx, key, value); err != nil {
+		c.Invalidate(key)
+		return err
+	}
+
+	c.store(key, value, false)
+
+	return nil
+}
+
+// Delete removes the key from the backend, or buffers the deletion in write-behind mode.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if c.opts.WriteBehind {
+		// Preserve the backend semantics for missing keys
+		if _, err := c.GetRaw(ctx, key); err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		c.pending[key] = pendingWrite{deleted: true}
+		c.mu.Unlock()
+
+		c.Invalidate(key)
+
+		return nil
+	}
+
+	err := c.kv.Delete(ctx, key)
+	c.Invalidate(key)
+
+	return err
+}
+
+// List lists the keys from the backend, including the pending writes in write-behind mode.
+func (c *Cache) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := c.kv.List(ctx, prefix)
+	if err != nil || !c.opts.WriteBehind {
+		return keys, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) == 0 && len(c.flushing) == 0 {
+		return keys, nil
+	}
+
+	result := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+
+	for _, k := range keys {
+		seen[k] = true
+		if p, ok := c.pendingWrite(k); !ok || !p.deleted {
+			result = append(result, k)
+		}
+	}
+
+	for _, writes := range []map[string]pendingWrite{c.pending, c.flushing} {
+		for k := range writes {
+			if p, _ := c.pendingWrite(k); !seen[k] && !p.deleted && strings.HasPrefix(k, prefix) {
+				seen[k] = true
+				result = append(result, k)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// BatchGetRaw returns the values of the keys, retrieving the missing ones from the backend in a single batch
+// if it implements models.KVWithBatch.
+func (c *Cache) BatchGetRaw(ctx context.Context, keys []string) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+	misses := make([]string, 0, len(keys))
+	generations := make(map[string]uint64, len(keys))
+
+	for _, k := range keys {
+		c.mu.Lock()
+		p, isPending := c.pendingWrite(k)
+		e, isCached := c.lookup(k)
+		_, isMiss := generations[k]
+
+		switch {
+		case isPending && !p.deleted:
+			c.stats.Hits++
+			result[k] = p.value
+		case isPending, isCached && e.missing:
+			c.stats.NegativeHits++
+		case isCached:
+			c.stats.Hits++
+			result[k] = e.value
+		case isMiss:
+			// Duplicate key
+		default:
+			c.stats.Misses++
+			misses = append(misses, k)
+			generations[k] = c.beginFetch(k)
+		}
+		c.mu.Unlock()
+	}
+
+	if len(misses) == 0 {
+		return result, nil
+	}
+
+	raws, err := c.getMany(ctx, misses)
+
+	if err != nil {
+		c.mu.Lock()
+		for _, k := range misses {
+			c.endFetch(k, generations[k])
+		}
+		c.mu.Unlock()
+
+		return nil, err
+	}
+
+	for _, k := range misses {
+		value, ok := raws[k]
+		if !ok {
+			c.mu.Lock()
+			c.endFetch(k, generations[k])
+			c.mu.Unlock()
+
+			continue
+		}
+
+		c.fill(k, generations[k], value, false)
+		result[k] = value
+	}
+
+	return result, nil
+}
+
+// BatchSetRaw writes the values to the backend in a single batch if it implements models.KVWithBatch,
+// or buffers them in write-behind mode, and caches them.
+func (c *Cache) BatchSetRaw(ctx context.Context, kv map[string][]byte) error {
+	if !c.opts.WriteBehind {
+		if err := c.setMany(ctx, kv); err != nil {
+			for k := range kv {
+				c.Invalidate(k)
+			}
+			return err
+		}
+	} else {
+		c.mu.Lock()
+		for k, v := range kv {
+			c.pending[k] = pendingWrite{value: v}
+		}
+		c.mu.Unlock()
+	}
+
+	for k, v := range kv {
+		c.store(k, v, false)
+	}
+
+	return nil
+}
+
+// BatchDelete removes the keys from the backend in a single batch if it implements models.KVWithBatch,
+// or buffers the deletions in write-behind mode.
+func (c *Cache) BatchDelete(ctx context.Context, keys []string) error {
+	if c.opts.WriteBehind {
+		// Preserve the backend semantics for missing keys
+		for _, k := range keys {
+			if _, err := c.GetRaw(ctx, k); err != nil {
+				return err
+			}
+		}
+
+		c.mu.Lock()
+		for _, k := range keys {
+			c.pending[k] = pendingWrite{deleted: true}
+		}
+		c.mu.Unlock()
+	} else if batch, ok := c.kv.(models.KVWithBatch); ok {
+		if err := batch.BatchDelete(ctx, keys); err != nil {
+			c.invalidateAll(keys)
+			return err
+		}
+	} else {
+		for _, k := range keys {
+			if err := c.kv.Delete(ctx, k); err != nil {
+				c.invalidateAll(keys)
+				return err
+			}
+		}
+	}
+
+	c.invalidateAll(keys)
+
+	return nil
+}
+
+// Health checks the health of the backend if it implements models.KVWithHealth.
+func (c *Cache) Health(ctx context.Context) error {
+	if h, ok := c.kv.(models.KVWithHealth); ok {
+		return h.Health(ctx)
+	}
+
+	return nil
+}
+
+// KeyLimits returns the key limits of the backend if it implements models.KVWithKeyLimits.
+func (c *Cache) KeyLimits() models.KeyLimits {
+	if kl, ok := c.kv.(models.KVWithKeyLimits); ok {
+		return kl.KeyLimits()
+	}
+
+	return models.KeyLimits{}
+}
+
+// SetRawWithTTL writes the value to the backend with an expiration if it implements models.KVWithTTL,
+// and caches it until it expires. The value is not buffered in write-behind mode.
+func (c *Cache) SetRawWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	kv, ok := c.kv.(models.KVWithTTL)
+	if !ok {
+		return fmt.Errorf("ttl not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	if err := c.Flush(ctx); err != nil {
+		return err
+	}
+
+	if err := kv.SetRawWithTTL(ctx, key, value, ttl); err != nil {
+		c.Invalidate(key)
+		return err
+	}
+
+	c.storeExpiring(key, value, ttl)
+
+	return nil
+}
+
+// TTL returns the remaining time to live of the key from the backend if it implements models.KVWithTTL.
+func (c *Cache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	kv, ok := c.kv.(models.KVWithTTL)
+	if !ok {
+		return 0, fmt.Errorf("ttl not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	if err := c.Flush(ctx); err != nil {
+		return 0, err
+	}
+
+	return kv.TTL(ctx, key)
+}
+
+// Persist removes the expiration of the key in the backend if it implements models.KVWithTTL.
+func (c *Cache) Persist(ctx context.Context, key string) error {
+	kv, ok := c.kv.(models.KVWithTTL)
+	if !ok {
+		return fmt.Errorf("ttl not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	if err := c.Flush(ctx); err != nil {
+		return err
+	}
+
+	return kv.Persist(ctx, key)
+}
+
+// GetRawWithVersion retrieves the value of the key and its version from the backend if it implements models.KVWithCAS.
+func (c *Cache) GetRawWithVersion(ctx context.Context, key string) ([]byte, uint64, error) {
+	cas, err := c.cas(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return cas.GetRawWithVersion(ctx, key)
+}
+
+// SetRawIfVersion writes the value to the backend if the version of the key matches and the backend implements
+// models.KVWithCAS, and caches it.
+func (c *Cache) SetRawIfVersion(ctx context.Context, key string, value []byte, version uint64) (uint64, error) {
+	cas, err := c.cas(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	newVersion, err := cas.SetRawIfVersion(ctx, key, value, version)
+	if err != nil {
+		c.Invalidate(key)
+		return 0, err
+	}
+
+	c.store(key, value, false)
+
+	return newVersion, nil
+}
+
+// SetRawIfAbsent writes the value to the backend if the key does not exist and the backend implements
+// models.KVWithCAS, and caches it.
+func (c *Cache) SetRawIfAbsent(ctx context.Context, key string, value []byte) (uint64, error) {
+	cas, err := c.cas(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := cas.SetRawIfAbsent(ctx, key, value)
+	if err != nil {
+		c.Invalidate(key)
+		return 0, err
+	}
+
+	c.store(key, value, false)
+
+	return version, nil
+}
+
+// DeleteIfVersion removes the key from the backend if its version matches and the backend implements
+// models.KVWithCAS, and invalidates it.
+func (c *Cache) DeleteIfVersion(ctx context.Context, key string, version uint64) error {
+	cas, err := c.cas(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer c.Invalidate(key)
+
+	return cas.DeleteIfVersion(ctx, key, version)
+}
+
+// CommitTxn commits the operations in the backend if it implements models.KVWithTxn,
+// and invalidates the keys they write.
+func (c *Cache) CommitTxn(ctx context.Context, ops []models.TxnOp) error {
+	kv, ok := c.kv.(models.KVWithTxn)
+	if !ok {
+		return fmt.Errorf("transactions not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	if err := c.Flush(ctx); err != nil {
+		return err
+	}
+
+	defer func() {
+		for _, op := range ops {
+			if op.Type != models.TxnOpCheckAbsent {
+				c.Invalidate(op.Key)
+			}
+		}
+	}()
+
+	return kv.CommitTxn(ctx, ops)
+}
+
+// ScanRaw returns a page of entries from the backend if it implements models.KVWithScan.
+func (c *Cache) ScanRaw(ctx context.Context, opts models.ScanOptions) (models.ScanPage, error) {
+	scanner, ok := c.kv.(models.KVWithScan)
+	if !ok {
+		return models.ScanPage{}, fmt.Errorf("scan not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	if err := c.Flush(ctx); err != nil {
+		return models.ScanPage{}, err
+	}
+
+	return scanner.ScanRaw(ctx, opts)
+}
+
+// WatchRaw reports the changes made to the keys starting with prefix if the backend implements models.KVWithWatch.
+// Pending writes are reported once flushed.
+func (c *Cache) WatchRaw(ctx context.Context, prefix string) (<-chan models.WatchEvent, error) {
+	watcher, ok := c.kv.(models.KVWithWatch)
+	if !ok {
+		return nil, fmt.Errorf("watch not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	return watcher.WatchRaw(ctx, prefix)
+}
+
+// Close flushes the pending writes and closes the backend.
+// Subsequent calls return the result of the first one.
+func (c *Cache) Close() error {
+	c.closeOnce.Do(func() {
+		var flushErr error
+
+		if c.opts.WriteBehind {
+			close(c.stop)
+			<-c.done
+
+			flushErr = c.Flush(context.Background())
+		}
+
+		c.closeErr = errors.Join(flushErr, c.kv.Close())
+	})
+
+	return c.closeErr
+}
+
+// Flush writes the pending writes to the backend. It is a no-op unless write-behind mode is enabled.
+// Writes that fail are kept pending unless superseded by a newer write.
+func (c *Cache) Flush(ctx context.Context) error {
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[string]pendingWrite)
+	c.flushing = pending
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.flushing = nil
+		c.mu.Unlock()
+	}()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	sets := make(map[string][]byte)
+	var deletes []string
+
+	for k, p := range pending {
+		if p.deleted {
+			deletes = append(deletes, k)
+		} else {
+			sets[k] = p.value
+		}
+	}
+
+	var errList []error
+
+	if len(sets) > 0 {
+		if err := c.setMany(ctx, sets); err != nil {
+			errList = append(errList, err)
+			c.requeue(pending, keysOf(sets))
+		}
+	}
+
+	for _, k := range deletes {
+		if err := c.kv.Delete(ctx, k); err != nil && !errors.Is(err, errs.ErrNotFound) {
+			errList = append(errList, fmt.Errorf("failed to delete key %s: %w", k, err))
+			c.requeue(pending, []string{k})
+		}
+	}
+
+	if len(errList) > 0 {
+		return fmt.Errorf("failed to flush pending writes: %w", errors.Join(errList...))
+	}
+
+	return nil
+}
+
+// Invalidate removes the key from the cache so that the next read goes to the backend.
+// Pending writes are not affected.
+func (c *Cache) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(key)
+}
+
+// Purge removes every entry from the cache. Pending writes are not affected.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+
+	for _, f := range c.fetches {
+		f.generation++
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.stats
+	s.Entries = c.lru.Len()
+	s.Bytes = c.bytes
+	s.PendingWrites = len(c.pending)
+
+	return s
+}
+
+// Attach registers a hook on the given client that invalidates the cache whenever the client writes a key,
+// including raw writes and the values rewritten by RotateKeys.
+// This keeps the cache consistent when writes bypass it, e.g. when the client is built directly on the backend.
+// Returns a function that removes the hook.
+//
+// Example:
+//
+//	readCache := cache.New(backend, cache.Option{TTL: time.Minute})
+//	writer, _ := kivigo.New(backend)
+//	detach := readCache.Attach(writer)
+//	defer detach()
+func (c *Cache) Attach(cl client.Client) func() {
+	_, _, unregister := cl.RegisterHook(func(_ context.Context, _ client.EventType, key string, _ []byte) error {
+		c.Invalidate(key)
+		return nil
+	}, client.HookOptions{
+		Events: []client.EventType{
+			client.EventSet, client.EventSetRaw, client.EventDelete, client.EventDeleteRaw,
+			client.EventBatchSet, client.EventBatchDel,
+		},
+	})
+
+	return unregister
+}
+
+// flushLoop flushes the pending writes every FlushInterval until the cache is closed.
+func (c *Cache) flushLoop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if err := c.Flush(context.Background()); err != nil && c.opts.OnFlushError != nil {
+				c.opts.OnFlushError(err)
+			}
+		}
+	}
+}
+
+// getMany reads the values from the backend in a single batch if supported.
+func (c *Cache) getMany(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if batch, ok := c.kv.(models.KVWithBatch); ok {
+		return batch.BatchGetRaw(ctx, keys)
+	}
+
+	result := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		value, err := c.kv.GetRaw(ctx, k)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = value
+	}
+
+	return result, nil
+}
+
+// setMany writes the values to the backend in a single batch if supported.
+func (c *Cache) setMany(ctx context.Context, kv map[string][]byte) error {
+	if batch, ok := c.kv.(models.KVWithBatch); ok {
+		return batch.BatchSetRaw(ctx, kv)
+	}
+
+	for k, v := range kv {
+		if err := c.kv.SetRaw(ctx, k, v); err != nil {
+			return fmt.Errorf("failed to set key %s: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+// cas returns the backend as a models.KVWithCAS once the pending writes are flushed.
+func (c *Cache) cas(ctx context.Context) (models.KVWithCAS, error) {
+	cas, ok := c.kv.(models.KVWithCAS)
+	if !ok {
+		return nil, fmt.Errorf("compare-and-swap not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	if err := c.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	return cas, nil
+}
+
+// requeue puts failed writes back in the pending writes unless a newer write superseded them.
+func (c *Cache) requeue(failed map[string]pendingWrite, keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, k := range keys {
+		if _, ok := c.pending[k]; !ok {
+			c.pending[k] = failed[k]
+		}
+	}
+}
+
+// lookup returns the cached entry of the key if it has not expired, and marks it as recently used.
+// The caller must hold the lock.
+func (c *Cache) lookup(key string) (*entry, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry) //nolint:forcetypeassert // only entries are stored
+	if !e.expiresAt.IsZero() && !e.expiresAt.After(now()) {
+		c.removeElement(el)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(el)
+
+	return e, true
+}
+
+// store caches the value written to the key.
+func (c *Cache) store(key string, value []byte, missing bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(key)
+	c.put(key, value, missing)
+}
+
+// storeExpiring caches the value written to the key with an expiration, for no longer than ttl.
+func (c *Cache) storeExpiring(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(key)
+	c.put(key, value, false)
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry) //nolint:forcetypeassert // only entries are stored
+		if expiresAt := now().Add(ttl); e.expiresAt.IsZero() || expiresAt.Before(e.expiresAt) {
+			e.expiresAt = expiresAt
+		}
+	}
+}
+
+// beginFetch records a read of the key from the backend and returns the generation of the key,
+// to be passed to fill once the read completes.
+// The caller must hold the lock.
+func (c *Cache) beginFetch(key string) uint64 {
+	f, ok := c.fetches[key]
+	if !ok {
+		f = &fetch{}
+		c.fetches[key] = f
+	}
+	f.readers++
+
+	return f.generation
+}
+
+// endFetch completes a read of the key from the backend started at the given generation.
+// Returns false if the key was written or invalidated during the read, in which case the value read is stale.
+// The caller must hold the lock.
+func (c *Cache) endFetch(key string, generation uint64) bool {
+	f := c.fetches[key]
+	f.readers--
+	if f.readers == 0 {
+		delete(c.fetches, key)
+	}
+
+	return f.generation == generation
+}
+
+// fill completes a read of the key from the backend started at the given generation, and caches the value read
+// unless the key was written or invalidated in the meantime.
+func (c *Cache) fill(key string, generation uint64, value []byte, missing bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.endFetch(key, generation) {
+		c.put(key, value, missing)
+	}
+}
+
+// invalidate removes the key from the cache and discards the values being read from the backend for it.
+// The caller must hold the lock.
+func (c *Cache) invalidate(key string) {
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+
+	if f, ok := c.fetches[key]; ok {
+		f.generation++
+	}
+}
+
+// pendingWrite returns the write of the key waiting to be flushed or being flushed, if any.
+// The caller must hold the lock.
+func (c *Cache) pendingWrite(key string) (pendingWrite, bool) {
+	if p, ok := c.pending[key]; ok {
+		return p, true
+	}
+
+	p, ok := c.flushing[key]
+
+	return p, ok
+}
+
+// put caches the value of the key and evicts the least recently used entries if limits are exceeded.
+// The caller must hold the lock.
+func (c *Cache) put(key string, value []byte, missing bool) {
+	e := &entry{key: key, value: value, missing: missing}
+
+	ttl := c.opts.TTL
+	if missing {
+		ttl = c.opts.NegativeTTL
+	}
+	if ttl > 0 {
+		e.expiresAt = now().Add(ttl)
+	}
+
+	size := e.size()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+
+	// Values larger than the cache are never cached
+	if c.opts.MaxBytes > 0 && size > c.opts.MaxBytes {
+		return
+	}
+
+	c.items[key] = c.lru.PushFront(e)
+	c.bytes += size
+
+	for (c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries) || (c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes) {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// invalidateAll removes the given keys from the cache.
+func (c *Cache) invalidateAll(keys []string) {
+	for _, k := range keys {
+		c.Invalidate(k)
+	}
+}
+
+// removeElement removes the element from the cache.
+// The caller must hold the lock.
+func (c *Cache) removeElement(el *list.Element) {
+	e := el.Value.(*entry) //nolint:forcetypeassert // only entries are stored
+
+	c.lru.Remove(el)
+	delete(c.items, e.key)
+	c.bytes -= e.size()
+}
+
+// size returns the number of bytes accounted for the entry.
+func (e *entry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+// keysOf returns the keys of the map.
+func keysOf(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -0,0 +1,748 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// basicKV hides every optional capability of the wrapped backend.
+type basicKV struct {
+	models.KV
+}
+
+// countingKV counts the reads and writes reaching the backend.
+type countingKV struct {
+	*mock.MockKV
+	gets atomic.Int64
+	sets atomic.Int64
+}
+
+func (c *countingKV) GetRaw(ctx context.Context, key string) ([]byte, error) {
+	c.gets.Add(1)
+	return c.MockKV.GetRaw(ctx, key)
+}
+
+func (c *countingKV) SetRaw(ctx context.Context, key string, value []byte) error {
+	c.sets.Add(1)
+	return c.MockKV.SetRaw(ctx, key, value)
+}
+
+func (c *countingKV) BatchSetRaw(ctx context.Context, kv map[string][]byte) error {
+	c.sets.Add(int64(len(kv)))
+	return c.MockKV.BatchSetRaw(ctx, kv)
+}
+
+func newCountingKV() *countingKV {
+	return &countingKV{MockKV: &mock.MockKV{Data: map[string][]byte{}}}
+}
+
+func TestCache_ReadThrough(t *testing.T) {
+	backend := newCountingKV()
+	backend.Data["k"] = []byte("v")
+
+	c := New(backend, Option{})
+	ctx := context.Background()
+
+	for range 3 {
+		v, err := c.GetRaw(ctx, "k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != "v" {
+			t.Errorf("GetRaw() = %q, want %q", v, "v")
+		}
+	}
+
+	if got := backend.gets.Load(); got != 1 {
+		t.Errorf("expected 1 backend read, got %d", got)
+	}
+
+	s := c.Stats()
+	if s.Hits != 2 || s.Misses != 1 || s.Entries != 1 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestCache_WriteThrough(t *testing.T) {
+	backend := newCountingKV()
+	c := New(backend, Option{})
+	ctx := context.Background()
+
+	if err := c.SetRaw(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if string(backend.Data["k"]) != "v" {
+		t.Errorf("expected value written to the backend, got %q", backend.Data["k"])
+	}
+
+	if _, err := c.GetRaw(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if got := backend.gets.Load(); got != 0 {
+		t.Errorf("expected the written value to be cached, got %d backend reads", got)
+	}
+
+	if err := c.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetRaw(ctx, "k"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	current := time.Unix(1000, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	backend := newCountingKV()
+	backend.Data["k"] = []byte("v1")
+
+	c := New(backend, Option{TTL: time.Minute})
+	ctx := context.Background()
+
+	if _, err := c.GetRaw(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	backend.Data["k"] = []byte("v2")
+
+	v, _ := c.GetRaw(ctx, "k")
+	if string(v) != "v1" {
+		t.Errorf("GetRaw() before expiration = %q, want %q", v, "v1")
+	}
+
+	current = current.Add(time.Minute)
+
+	v, _ = c.GetRaw(ctx, "k")
+	if string(v) != "v2" {
+		t.Errorf("GetRaw() after expiration = %q, want %q", v, "v2")
+	}
+}
+
+func TestCache_NegativeCaching(t *testing.T) {
+	current := time.Unix(1000, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	backend := newCountingKV()
+	c := New(backend, Option{NegativeTTL: time.Second})
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := c.GetRaw(ctx, "missing"); !errors.Is(err, errs.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+
+	if got := backend.gets.Load(); got != 1 {
+		t.Errorf("expected 1 backend read, got %d", got)
+	}
+	if s := c.Stats(); s.NegativeHits != 2 {
+		t.Errorf("expected 2 negative hits, got %d", s.NegativeHits)
+	}
+
+	current = current.Add(time.Second)
+
+	if _, err := c.GetRaw(ctx, "missing"); !errors.Is(err, errs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if got := backend.gets.Load(); got != 2 {
+		t.Errorf("expected the negative entry to expire, got %d backend reads", got)
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	backend := newCountingKV()
+	ctx := context.Background()
+
+	t.Run("max entries", func(t *testing.T) {
+		c := New(backend, Option{MaxEntries: 2})
+
+		_ = c.SetRaw(ctx, "a", []byte("1"))
+		_ = c.SetRaw(ctx, "b", []byte("2"))
+		_, _ = c.GetRaw(ctx, "a") // "b" becomes the least recently used entry
+		_ = c.SetRaw(ctx, "c", []byte("3"))
+
+		s := c.Stats()
+		if s.Entries != 2 || s.Evictions != 1 {
+			t.Errorf("unexpected stats: %+v", s)
+		}
+
+		c.mu.Lock()
+		_, hasA := c.items["a"]
+		_, hasB := c.items["b"]
+		c.mu.Unlock()
+
+		if !hasA || hasB {
+			t.Errorf("expected %q to be evicted and %q to be kept", "b", "a")
+		}
+	})
+
+	t.Run("max bytes", func(t *testing.T) {
+		c := New(backend, Option{MaxBytes: 9})
+
+		_ = c.SetRaw(ctx, "a", []byte("1234"))
+		_ = c.SetRaw(ctx, "b", []byte("1234"))
+
+		if s := c.Stats(); s.Entries != 1 || s.Bytes != 5 {
+			t.Errorf("unexpected stats: %+v", s)
+		}
+
+		_ = c.SetRaw(ctx, "large", []byte("12345678901"))
+
+		if s := c.Stats(); s.Entries != 1 || s.Bytes != 5 {
+			t.Errorf("expected values larger than the cache not to be cached: %+v", s)
+		}
+	})
+}
+
+func TestCache_BatchGetRaw(t *testing.T) {
+	backend := newCountingKV()
+	backend.Data["a"] = []byte("1")
+	backend.Data["b"] = []byte("2")
+
+	c := New(backend, Option{})
+	ctx := context.Background()
+
+	if _, err := c.GetRaw(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.BatchGetRaw(ctx, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got["a"]) != "1" || string(got["b"]) != "2" {
+		t.Errorf("unexpected values: %v", got)
+	}
+
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 2 || s.Entries != 2 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestCache_WriteBehind(t *testing.T) {
+	backend := newCountingKV()
+	c := New(backend, Option{WriteBehind: true, FlushInterval: time.Hour})
+	ctx := context.Background()
+
+	for _, v := range []string{"1", "2", "3"} {
+		if err := c.SetRaw(ctx, "k", []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.SetRaw(ctx, "other", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backend.Data) != 0 {
+		t.Errorf("expected no backend writes before flush, got %v", backend.Data)
+	}
+
+	v, err := c.GetRaw(ctx, "k")
+	if err != nil || string(v) != "3" {
+		t.Errorf("GetRaw() = (%q, %v), want the pending value", v, err)
+	}
+
+	keys, err := c.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("expected pending keys to be listed, got %v", keys)
+	}
+
+	if s := c.Stats(); s.PendingWrites != 2 {
+		t.Errorf("expected 2 pending writes, got %d", s.PendingWrites)
+	}
+
+	if err := c.Delete(ctx, "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := backend.sets.Load(); got != 1 {
+		t.Errorf("expected writes to be coalesced into 1 backend write, got %d", got)
+	}
+	if string(backend.Data["k"]) != "3" {
+		t.Errorf("expected the last value to be flushed, got %q", backend.Data["k"])
+	}
+	if _, ok := backend.Data["other"]; ok {
+		t.Error("expected the deleted key not to be written")
+	}
+}
+
+func TestCache_WriteBehindFlushError(t *testing.T) {
+	backend := &failingKV{countingKV: newCountingKV(), fail: true}
+	c := New(backend, Option{WriteBehind: true, FlushInterval: time.Hour})
+	ctx := context.Background()
+
+	_ = c.SetRaw(ctx, "k", []byte("v"))
+
+	if err := c.Flush(ctx); err == nil {
+		t.Fatal("expected flush error")
+	}
+	if s := c.Stats(); s.PendingWrites != 1 {
+		t.Errorf("expected the failed write to stay pending, got %d", s.PendingWrites)
+	}
+
+	backend.fail = false
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(backend.Data["k"]) != "v" {
+		t.Errorf("expected the write to be retried, got %q", backend.Data["k"])
+	}
+}
+
+func TestCache_ReadThroughConcurrentWrite(t *testing.T) {
+	for name, write := range map[string]func(c *Cache) error{
+		"set": func(c *Cache) error {
+			return c.SetRaw(context.Background(), "k", []byte("new"))
+		},
+		"delete": func(c *Cache) error {
+			return c.Delete(context.Background(), "k")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			backend := &gatedKV{countingKV: newCountingKV(), read: make(chan struct{}), release: make(chan struct{})}
+			backend.Data["k"] = []byte("old")
+
+			c := New(backend, Option{NegativeTTL: time.Hour})
+			ctx := context.Background()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, _ = c.GetRaw(ctx, "k")
+			}()
+
+			// The read has retrieved the old value but not cached it yet
+			<-backend.read
+			backend.read = nil
+
+			if err := write(c); err != nil {
+				t.Fatal(err)
+			}
+
+			close(backend.release)
+			<-done
+
+			value, err := c.GetRaw(ctx, "k")
+			if got, want := string(value), string(backend.Data["k"]); got != want {
+				t.Errorf("GetRaw() = (%q, %v), want %q: the stale value was cached", got, err, want)
+			}
+		})
+	}
+}
+
+func TestCache_WriteBehindFlushWindow(t *testing.T) {
+	backend := &gatedKV{countingKV: newCountingKV(), setting: make(chan struct{}), release: make(chan struct{})}
+	c := New(backend, Option{WriteBehind: true, FlushInterval: time.Hour, NegativeTTL: time.Hour})
+	ctx := context.Background()
+
+	if err := c.SetRaw(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- c.Flush(ctx) }()
+
+	// The write is being flushed and its cache entry was evicted
+	<-backend.setting
+	c.Purge()
+
+	if value, err := c.GetRaw(ctx, "k"); err != nil || string(value) != "v" {
+		t.Errorf("GetRaw() = (%q, %v), want the value being flushed", value, err)
+	}
+
+	close(backend.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := c.GetRaw(ctx, "k"); err != nil || string(value) != "v" {
+		t.Errorf("GetRaw() = (%q, %v), want the flushed value", value, err)
+	}
+}
+
+func TestCache_WriteBehindBatchDeleteMissing(t *testing.T) {
+	backend := newCountingKV()
+	backend.Data["a"] = []byte("1")
+
+	c := New(backend, Option{WriteBehind: true, FlushInterval: time.Hour})
+	ctx := context.Background()
+
+	if err := c.BatchDelete(ctx, []string{"a", "missing"}); !errors.Is(err, errs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if s := c.Stats(); s.PendingWrites != 0 {
+		t.Errorf("expected no pending deletion, got %d", s.PendingWrites)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing twice returns the result of the first call
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache_Attach(t *testing.T) {
+	backend := newCountingKV()
+	backend.Data["k"] = []byte(`"v1"`)
+
+	readCache := New(backend, Option{})
+	ctx := context.Background()
+
+	writer, err := client.New(backend, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detach := readCache.Attach(writer)
+
+	if _, err := readCache.GetRaw(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Set(ctx, "k", "v2"); err != nil {
+		t.Fatal(err)
+	}
+
+	v, _ := readCache.GetRaw(ctx, "k")
+	if string(v) != `"v2"` {
+		t.Errorf("expected the cache to be invalidated by the client write, got %q", v)
+	}
+
+	if err := writer.SetRaw(ctx, "k", []byte(`"raw"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	v, _ = readCache.GetRaw(ctx, "k")
+	if string(v) != `"raw"` {
+		t.Errorf("expected the cache to be invalidated by the raw write, got %q", v)
+	}
+
+	if err := writer.DeleteRaw(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readCache.GetRaw(ctx, "k"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected the cache to be invalidated by the raw delete, got %v", err)
+	}
+
+	if err := writer.Set(ctx, "k", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCache.GetRaw(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	detach()
+
+	if err := writer.Set(ctx, "k", "v3"); err != nil {
+		t.Fatal(err)
+	}
+
+	v, _ = readCache.GetRaw(ctx, "k")
+	if string(v) != `"v2"` {
+		t.Errorf("expected the detached cache to keep its entry, got %q", v)
+	}
+}
+
+func TestCache_AttachRotateKeys(t *testing.T) {
+	backend := newCountingKV()
+	readCache := New(backend, Option{})
+	ctx := context.Background()
+
+	keys := &client.StaticKeys{Current: "k1", Keys: map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	}}
+	opts := client.WithTransformers(client.Encrypt(keys))(client.Option{Encoder: json.New()})
+
+	writer, err := client.New(backend, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := client.New(readCache, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detach := readCache.Attach(writer)
+	defer detach()
+
+	if err := writer.Set(ctx, "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if err := reader.Get(ctx, "k", &got); err != nil {
+		t.Fatal(err)
+	}
+
+	keys.Current = "k2"
+	if err := writer.RotateKeys(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	delete(keys.Keys, "k1")
+
+	// The cached value encrypted with the retired key must have been invalidated
+	if err := reader.Get(ctx, "k", &got); err != nil || got != "v" {
+		t.Errorf("Get() = (%q, %v), want the rotated value", got, err)
+	}
+}
+
+func TestCache_AttachPersist(t *testing.T) {
+	backend := newCountingKV()
+	readCache := New(backend, Option{})
+	ctx := context.Background()
+
+	// Conditional writes store the TTL of the client in a client-side expiration envelope
+	writer, err := client.New(backend, client.Option{Encoder: json.New(), TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detach := readCache.Attach(writer)
+	defer detach()
+
+	if _, err := writer.SetIfAbsent(ctx, "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCache.GetRaw(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Persist(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cached value must no longer carry the expiration
+	v, err := readCache.GetRaw(ctx, "k")
+	if err != nil || string(v) != `"v"` {
+		t.Errorf("GetRaw() = (%q, %v), want the persisted value", v, err)
+	}
+}
+
+func TestCache_AsClientBackend(t *testing.T) {
+	backend := newCountingKV()
+	c := New(backend, Option{})
+
+	cl, err := client.New(c, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "config", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if err := cl.Get(ctx, "config", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != "value" {
+		t.Errorf("Get() = %q, want %q", got, "value")
+	}
+
+	if err := cl.BatchDelete(ctx, []string{"config"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cl.Get(ctx, "config", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after batch delete, got %v", err)
+	}
+	if got := backend.gets.Load(); got != 1 {
+		t.Errorf("expected 1 backend read, got %d", got)
+	}
+}
+
+func TestCache_AsClientBackendCapabilities(t *testing.T) {
+	current := time.Unix(1000, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	backend := newCountingKV()
+	backend.Clock = func() time.Time { return current }
+
+	cl, err := client.New(New(backend, Option{}), client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := cl.Set(ctx, "session", "token", client.WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The backend expires the value natively instead of the client-side envelope
+	if string(backend.Data["session"]) != `"token"` {
+		t.Errorf("expected unwrapped value, got %q", backend.Data["session"])
+	}
+	if ttl, err := cl.TTL(ctx, "session"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL() = (%v, %v), want %v", ttl, err, time.Minute)
+	}
+
+	var got string
+	if err := cl.Get(ctx, "session", &got); err != nil || got != "token" {
+		t.Errorf("Get() = (%q, %v), want %q", got, err, "token")
+	}
+
+	current = current.Add(2 * time.Minute)
+
+	// The cached value expires with the backend value
+	if err := cl.Get(ctx, "session", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after expiration, got %v", err)
+	}
+
+	if err := cl.Set(ctx, "a", "old"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.SetIfAbsent(ctx, "lock", "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cl.SetIfAbsent(ctx, "lock", "other"); !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+
+	if err := cl.Txn(ctx, func(tx client.Tx) error {
+		if err := tx.Set("a", "new"); err != nil {
+			return err
+		}
+		return tx.Delete("lock")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The keys written by the transaction are no longer served from the cache
+	if err := cl.Get(ctx, "a", &got); err != nil || got != "new" {
+		t.Errorf("Get() = (%q, %v), want %q", got, err, "new")
+	}
+	if err := cl.Get(ctx, "lock", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after transaction, got %v", err)
+	}
+
+	var keys []string
+	for e, err := range cl.Iterate(ctx, client.IterateOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, e.Key)
+	}
+	if len(keys) != 1 || keys[0] != "a" {
+		t.Errorf("Iterate() keys = %v, want [a]", keys)
+	}
+}
+
+func TestCache_AsClientBackendUnsupportedCapabilities(t *testing.T) {
+	current := time.Unix(1000, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	backend := &mock.MockKV{Data: map[string][]byte{}}
+
+	cl, err := client.New(New(basicKV{backend}, Option{}), client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// The client falls back to its own expiration envelope
+	if err := cl.Set(ctx, "session", "token", client.WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if string(backend.Data["session"]) == `"token"` {
+		t.Errorf("expected value wrapped in an envelope, got %q", backend.Data["session"])
+	}
+
+	var got string
+	if err := cl.Get(ctx, "session", &got); err != nil || got != "token" {
+		t.Errorf("Get() = (%q, %v), want %q", got, err, "token")
+	}
+	if ttl, err := cl.TTL(ctx, "session"); err != nil || ttl <= 0 {
+		t.Errorf("TTL() = (%v, %v), want a positive TTL", ttl, err)
+	}
+
+	if _, err := cl.SetIfAbsent(ctx, "lock", "owner"); !errors.Is(err, errs.ErrOperationNotSupported) {
+		t.Errorf("expected ErrOperationNotSupported from SetIfAbsent, got %v", err)
+	}
+	if err := cl.Txn(ctx, func(tx client.Tx) error {
+		return tx.Set("a", "value")
+	}, client.WithCompensatingTxn()); err != nil {
+		t.Errorf("expected compensating transaction to succeed, got %v", err)
+	}
+
+	var keys []string
+	for e, err := range cl.Iterate(ctx, client.IterateOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, e.Key)
+	}
+	if len(keys) != 2 {
+		t.Errorf("Iterate() keys = %v, want [a session]", keys)
+	}
+}
+
+// failingKV fails every write while fail is set.
+type failingKV struct {
+	*countingKV
+	fail bool
+}
+
+func (f *failingKV) BatchSetRaw(ctx context.Context, kv map[string][]byte) error {
+	if f.fail {
+		return errors.New("backend unavailable")
+	}
+	return f.countingKV.BatchSetRaw(ctx, kv)
+}
+
+// gatedKV blocks reads and batch writes until release is closed, signalling on read and setting once they started.
+type gatedKV struct {
+	*countingKV
+	read    chan struct{}
+	setting chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedKV) GetRaw(ctx context.Context, key string) ([]byte, error) {
+	value, err := g.countingKV.GetRaw(ctx, key)
+	if g.read != nil {
+		g.read <- struct{}{}
+		<-g.release
+	}
+	return value, err
+}
+
+func (g *gatedKV) BatchSetRaw(ctx context.Context, kv map[string][]byte) error {
+	if g.setting != nil {
+		close(g.setting)
+		<-g.release
+	}
+	return g.countingKV.BatchSetRaw(ctx, kv)
+}
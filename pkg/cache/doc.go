@@ -0,0 +1,8 @@
+/*
+Package cache provides an in-process caching layer for KiviGo backends.
+
+The cache wraps any models.KV and can be used as the backend of a client. It supports
+LRU eviction with entry and byte limits, per-entry TTL, negative caching of missing keys,
+hit/miss statistics, and a write-behind mode that coalesces writes.
+*/
+package cache
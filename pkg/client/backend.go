@@ -15,7 +15,13 @@ func (c Client) HasKey(ctx context.Context, key string) (bool, error) {
 		return false, errs.ErrEmptyKey
 	}
 
-	_, err := c.GetRaw(ctx, key)
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return false, err
+	}
+
+	raw, err := c.getRaw(ctx, key)
+	c.runReadHooks(ctx, c.opts, key, raw, err)
 	if err != nil {
 		if errors.Is(err, errs.ErrNotFound) {
 			return false, nil
@@ -79,21 +85,32 @@ func (c Client) MatchKeys(ctx context.Context, prefix string, f MatchKeysFunc) (
 //
 //	var value string
 //	err := client.Get(ctx, "myKey", &value)
+//
+// Example with per-call options:
+//
+//	err := client.Get(ctx, "myKey", &value, client.WithEncoder(yaml.New()), client.WithTimeout(time.Second))
 func (c Client) Get(ctx context.Context, key string, value any, opts ...Options) error {
 	if key == "" {
 		return errs.ErrEmptyKey
 	}
 
-	for _, opt := range opts {
-		opt(c.opts)
+	o := c.callOptions(opts)
+
+	opCtx, cancel := o.context(ctx)
+	defer cancel()
+
+	key = o.key(key)
+	if err := c.validateKey(o, key); err != nil {
+		return err
 	}
 
-	vV, err := c.GetRaw(ctx, key)
+	vV, err := c.getRaw(opCtx, key)
+	c.runReadHooks(ctx, o, key, vV, err)
 	if err != nil {
 		return err
 	}
 
-	return c.opts.Encoder.Decode(ctx, vV, value)
+	return o.decode(opCtx, vV, value)
 }
 
 // Set stores the given value under the specified key.
@@ -102,30 +119,43 @@ func (c Client) Get(ctx context.Context, key string, value any, opts ...Options)
 // Example:
 //
 //	err := client.Set(ctx, "myKey", "myValue")
+//
+// Example with expiration:
+//
+//	err := client.Set(ctx, "myKey", "myValue", client.WithTTL(time.Minute))
 func (c Client) Set(ctx context.Context, key string, value any, opts ...Options) error {
 	if key == "" {
 		return errs.ErrEmptyKey
 	}
 
-	for _, opt := range opts {
-		opt(c.opts)
+	o := c.callOptions(opts)
+
+	key = o.key(key)
+	if err := c.validateKey(o, key); err != nil {
+		return err
 	}
 
-	vV, err := c.opts.Encoder.Encode(ctx, value)
+	opCtx, cancel := o.context(ctx)
+	defer cancel()
+
+	vV, err := o.encode(opCtx, value)
 	if err != nil {
 		return err
 	}
 
-	err = c.SetRaw(ctx, key, vV)
+	key, vV, err = c.runPreHooks(ctx, o, EventBeforeSet, key, vV)
 	if err != nil {
 		return err
 	}
 
-	// Trigger hooks after successful operation
-	if c.hooks != nil {
-		c.hooks.Run(ctx, EventSet, key, vV)
+	err = c.setRawWithTTL(opCtx, key, vV, o.TTL)
+	if err != nil {
+		return err
 	}
 
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, o, EventSet, key, vV)
+
 	return nil
 }
 
@@ -135,20 +165,33 @@ func (c Client) Set(ctx context.Context, key string, value any, opts ...Options)
 // Example:
 //
 //	err := client.Delete(ctx, "myKey")
-func (c Client) Delete(ctx context.Context, key string) error {
+func (c Client) Delete(ctx context.Context, key string, opts ...Options) error {
 	if key == "" {
 		return errs.ErrEmptyKey
 	}
 
-	err := c.KV.Delete(ctx, key)
+	o := c.callOptions(opts)
+
+	opCtx, cancel := o.context(ctx)
+	defer cancel()
+
+	key = o.key(key)
+	if err := c.validateKey(o, key); err != nil {
+		return err
+	}
+
+	key, _, err := c.runPreHooks(ctx, o, EventBeforeDelete, key, nil)
 	if err != nil {
 		return err
 	}
 
-	// Trigger hooks after successful operation
-	if c.hooks != nil {
-		c.hooks.Run(ctx, EventDelete, key, nil)
+	err = c.KV.Delete(opCtx, key)
+	if err != nil {
+		return err
 	}
 
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, o, EventDelete, key, nil)
+
 	return nil
 }
@@ -3,7 +3,9 @@ package client
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 
 	"github.com/kivigo/kivigo/pkg/errs"
 	"github.com/kivigo/kivigo/pkg/models"
@@ -22,23 +24,13 @@ import (
 //	    log.Fatal(err)
 //	}
 //	fmt.Println("Retrieved values:", values)
-func (c Client) BatchGet(ctx context.Context, keys []string, dest any) error { //nolint:cyclop
-	batch, ok := c.KV.(models.KVWithBatch)
-	if !ok {
-		return fmt.Errorf("BatchGet not supported by backend")
-	}
+func (c Client) BatchGet(ctx context.Context, keys []string, dest any, opts ...Options) error {
+	o := c.callOptions(opts)
 
-	if len(keys) == 0 {
-		return errs.ErrEmptyBatch
-	}
+	opCtx, cancel := o.context(ctx)
+	defer cancel()
 
-	for _, key := range keys {
-		if key == "" {
-			return errs.ErrEmptyKey
-		}
-	}
-
-	raws, err := batch.BatchGetRaw(ctx, keys)
+	raws, err := c.batchGetRaw(ctx, opCtx, o, keys)
 	if err != nil {
 		return err
 	}
@@ -62,7 +54,7 @@ func (c Client) BatchGet(ctx context.Context, keys []string, dest any) error { /
 	for k, raw := range raws {
 		// Create a zero value of the destination type to decode into
 		destValue := reflect.New(destValueType).Interface()
-		if err := c.opts.Encoder.Decode(ctx, raw, destValue); err != nil {
+		if err := o.decode(opCtx, raw, destValue); err != nil {
 			return fmt.Errorf("failed to decode value for key %s: %w", k, err)
 		}
 		// Set the decoded value in the destination map
@@ -72,13 +64,66 @@ func (c Client) BatchGet(ctx context.Context, keys []string, dest any) error { /
 	return nil
 }
 
+// batchGetRaw retrieves the raw values of the keys in a single batch operation, keyed by the keys requested
+// by the caller. Expired values are reported as missing keys. The hooks receive ctx, and the backend opCtx.
+func (c Client) batchGetRaw(ctx, opCtx context.Context, o Option, keys []string) (map[string][]byte, error) {
+	batch, ok := c.KV.(models.KVWithBatch)
+	if !ok {
+		return nil, fmt.Errorf("BatchGet not supported by backend")
+	}
+
+	if len(keys) == 0 {
+		return nil, errs.ErrEmptyBatch
+	}
+
+	for _, key := range keys {
+		if key == "" {
+			return nil, errs.ErrEmptyKey
+		}
+	}
+
+	keys = o.keys(keys)
+	if err := c.validateKeys(o, keys); err != nil {
+		return nil, err
+	}
+
+	raws, err := batch.BatchGetRaw(opCtx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]byte, len(raws))
+
+	for _, k := range keys {
+		raw, found := raws[k]
+		if found {
+			raw, err = stripTTL(raw)
+			found = err == nil
+		}
+
+		if !found {
+			c.runHooks(ctx, o, EventMiss, k, nil)
+			continue
+		}
+
+		c.runHooks(ctx, o, EventGet, k, raw)
+		result[k[len(o.KeyPrefix):]] = raw
+	}
+
+	return result, nil
+}
+
 // BatchSet sets multiple values in the key-value store in a single batch operation.
 // It takes a context, a map of keys to values, and returns an error if the backend does not support batch operations
 // or if encoding fails. The keys must be strings, and the values must be of a type that the encoder can encode.
 // Example usage:
 //
 //	err := client.BatchSet(ctx, map[string]string{"key1": "value1", "key2": "value2"})
-func (c Client) BatchSet(ctx context.Context, kv map[string]any) error {
+//
+// Example with expiration:
+//
+//	err := client.BatchSet(ctx, values, client.WithTTL(time.Minute))
+func (c Client) BatchSet(ctx context.Context, kv map[string]any, opts ...Options) error {
 	batch, ok := c.KV.(models.KVWithBatch)
 	if !ok {
 		return fmt.Errorf("BatchSet not supported by backend")
@@ -88,23 +133,35 @@ func (c Client) BatchSet(ctx context.Context, kv map[string]any) error {
 		return err
 	}
 
-	raws, err := c.encodeBatchValues(ctx, kv)
+	o := c.callOptions(opts)
+
+	for k := range kv {
+		if err := c.validateKey(o, o.key(k)); err != nil {
+			return err
+		}
+	}
+
+	opCtx, cancel := o.context(ctx)
+	defer cancel()
+
+	raws, err := encodeBatchValues(opCtx, o, kv)
 	if err != nil {
 		return err
 	}
 
-	err = batch.BatchSetRaw(ctx, raws)
+	raws, err = c.runBatchPreHooks(ctx, o, raws)
 	if err != nil {
 		return err
 	}
 
-	// Trigger hooks after successful operation
-	if c.hooks != nil {
-		for k, v := range raws {
-			c.hooks.Run(ctx, EventBatchSet, k, v)
-		}
+	err = c.batchSetRawWithTTL(opCtx, batch, raws, o.TTL)
+	if err != nil {
+		return err
 	}
 
+	// Trigger hooks after successful operation
+	c.runBatchHooks(ctx, o, EventBatchSet, slices.Collect(maps.Keys(raws)), raws)
+
 	return nil
 }
 
@@ -126,27 +183,53 @@ func (c Client) validateBatchSetInput(kv map[string]any) error {
 	return nil
 }
 
-// encodeBatchValues encodes all values in the batch.
-func (c Client) encodeBatchValues(ctx context.Context, kv map[string]any) (map[string][]byte, error) {
+// encodeBatchValues encodes all values in the batch, keyed by the keys as stored in the backend.
+func encodeBatchValues(ctx context.Context, o Option, kv map[string]any) (map[string][]byte, error) {
 	raws := make(map[string][]byte, len(kv))
 
 	for k, v := range kv {
-		raw, err := c.opts.Encoder.Encode(ctx, v)
+		raw, err := o.encode(ctx, v)
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode value for key %s: %w", k, err)
 		}
-		raws[k] = raw
+		raws[o.key(k)] = raw
 	}
 
 	return raws, nil
 }
 
+// runBatchPreHooks triggers the pre-hooks for each value of a batch, in key order.
+// The whole batch is rejected if a pre-hook rejects one of its values, or moves two of them to the same key.
+func (c Client) runBatchPreHooks(ctx context.Context, o Option, raws map[string][]byte) (map[string][]byte, error) {
+	if o.SkipHooks {
+		return raws, nil
+	}
+
+	result := make(map[string][]byte, len(raws))
+	origins := make(map[string]string, len(raws))
+
+	for _, k := range slices.Sorted(maps.Keys(raws)) {
+		key, raw, err := c.runPreHooks(ctx, o, EventBeforeSet, k, raws[k])
+		if err != nil {
+			return nil, err
+		}
+
+		if prev, dup := origins[key]; dup {
+			return nil, fmt.Errorf("%w: hook moved keys %q and %q to the same key %q", errs.ErrHookRejected, prev, k, key)
+		}
+		origins[key] = k
+		result[key] = raw
+	}
+
+	return result, nil
+}
+
 // BatchDelete removes multiple values from the key-value store in a single batch operation.
 // It takes a context and a slice of keys to delete, and returns an error if the backend does not support batch operations.
 // Example usage:
 //
 //	err := client.BatchDelete(ctx, []string{"key1", "key2"})
-func (c Client) BatchDelete(ctx context.Context, keys []string) error {
+func (c Client) BatchDelete(ctx context.Context, keys []string, opts ...Options) error {
 	batch, ok := c.KV.(models.KVWithBatch)
 	if !ok {
 		return fmt.Errorf("BatchDelete not supported by backend")
@@ -162,17 +245,42 @@ func (c Client) BatchDelete(ctx context.Context, keys []string) error {
 		}
 	}
 
-	err := batch.BatchDelete(ctx, keys)
-	if err != nil {
+	o := c.callOptions(opts)
+
+	opCtx, cancel := o.context(ctx)
+	defer cancel()
+
+	keys = o.keys(keys)
+	if err := c.validateKeys(o, keys); err != nil {
 		return err
 	}
 
-	// Trigger hooks after successful operation
-	if c.hooks != nil {
-		for _, k := range keys {
-			c.hooks.Run(ctx, EventBatchDel, k, nil)
+	if !o.SkipHooks {
+		keys = slices.Clone(keys)
+		origins := make(map[string]string, len(keys))
+
+		for i, k := range keys {
+			key, _, err := c.runPreHooks(ctx, o, EventBeforeDelete, k, nil)
+			if err != nil {
+				return err
+			}
+
+			// Keys repeated by the caller are not moved by the hook
+			if prev, dup := origins[key]; dup && prev != k {
+				return fmt.Errorf("%w: hook moved keys %q and %q to the same key %q", errs.ErrHookRejected, prev, k, key)
+			}
+			origins[key] = k
+			keys[i] = key
 		}
 	}
 
+	err := batch.BatchDelete(opCtx, keys)
+	if err != nil {
+		return err
+	}
+
+	// Trigger hooks after successful operation
+	c.runBatchHooks(ctx, o, EventBatchDel, keys, nil)
+
 	return nil
 }
@@ -0,0 +1,288 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// maxUpdateAttempts is the number of times Update retries after a version conflict.
+const maxUpdateAttempts = 10
+
+// GetWithVersion retrieves the value stored under the specified key, decodes it into value and returns its version.
+// The version can be passed to SetIfVersion or DeleteIfVersion to detect concurrent updates.
+// Returns errs.ErrOperationNotSupported if the backend does not implement models.KVWithCAS.
+//
+// Example:
+//
+//	var cfg Config
+//	version, err := client.GetWithVersion(ctx, "config", &cfg)
+func (c Client) GetWithVersion(ctx context.Context, key string, value any) (uint64, error) {
+	version, found, err := c.getWithVersion(ctx, key, value)
+	if err != nil {
+		return 0, err
+	}
+
+	if !found {
+		return 0, errs.ErrNotFound
+	}
+
+	return version, nil
+}
+
+// SetIfVersion stores the given value under the specified key only if its current version matches version.
+// A zero version means the key must not exist.
+// Returns the new version, or errs.ErrConflict if the key was modified concurrently.
+// If the client has a TTL, the value is stored in a client-side expiration envelope.
+//
+// Example:
+//
+//	newVersion, err := client.SetIfVersion(ctx, "config", cfg, version)
+//	if errors.Is(err, errs.ErrConflict) {
+//	    // Reload and retry
+//	}
+func (c Client) SetIfVersion(ctx context.Context, key string, value any, version uint64) (uint64, error) {
+	if key == "" {
+		return 0, errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return 0, err
+	}
+
+	cas, err := c.cas()
+	if err != nil {
+		return 0, err
+	}
+
+	raw, err := c.opts.encode(ctx, value)
+	if err != nil {
+		return 0, err
+	}
+
+	raw, err = c.runConditionalPreHooks(ctx, EventBeforeSet, key, raw)
+	if err != nil {
+		return 0, err
+	}
+
+	newVersion, err := cas.SetRawIfVersion(ctx, key, withTTL(raw, c.opts.TTL), version)
+	if err != nil {
+		return 0, err
+	}
+
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, c.opts, EventSet, key, raw)
+
+	return newVersion, nil
+}
+
+// SetIfAbsent stores the given value under the specified key only if the key does not exist.
+// A value whose client-side expiration has passed is considered missing.
+// Returns the new version, or errs.ErrConflict if the key already exists.
+// If the client has a TTL, the value is stored in a client-side expiration envelope.
+//
+// Example:
+//
+//	_, err := client.SetIfAbsent(ctx, "lock", owner)
+//	if errors.Is(err, errs.ErrConflict) {
+//	    // Lock already held
+//	}
+func (c Client) SetIfAbsent(ctx context.Context, key string, value any) (uint64, error) {
+	if key == "" {
+		return 0, errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return 0, err
+	}
+
+	cas, err := c.cas()
+	if err != nil {
+		return 0, err
+	}
+
+	raw, err := c.opts.encode(ctx, value)
+	if err != nil {
+		return 0, err
+	}
+
+	raw, err = c.runConditionalPreHooks(ctx, EventBeforeSet, key, raw)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.purgeExpired(ctx, key); err != nil {
+		return 0, err
+	}
+
+	version, err := cas.SetRawIfAbsent(ctx, key, withTTL(raw, c.opts.TTL))
+	if err != nil {
+		return 0, err
+	}
+
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, c.opts, EventSet, key, raw)
+
+	return version, nil
+}
+
+// DeleteIfVersion removes the specified key only if its current version matches version.
+// Returns errs.ErrConflict if the key was modified concurrently.
+//
+// Example:
+//
+//	err := client.DeleteIfVersion(ctx, "lock", version)
+func (c Client) DeleteIfVersion(ctx context.Context, key string, version uint64) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return err
+	}
+
+	cas, err := c.cas()
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.runConditionalPreHooks(ctx, EventBeforeDelete, key, nil); err != nil {
+		return err
+	}
+
+	if err := cas.DeleteIfVersion(ctx, key, version); err != nil {
+		return err
+	}
+
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, c.opts, EventDelete, key, nil)
+
+	return nil
+}
+
+// runConditionalPreHooks triggers the pre-hooks of a conditional operation on key and returns the value to write.
+// The version of the operation was read for key, so the pre-hooks cannot move the operation to another key.
+func (c Client) runConditionalPreHooks(ctx context.Context, evt EventType, key string, value []byte) ([]byte, error) {
+	newKey, value, err := c.runPreHooks(ctx, c.opts, evt, key, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if newKey != key {
+		return nil, fmt.Errorf("%w: hook moved the conditional operation on key %q to %q", errs.ErrHookRejected, key, newKey)
+	}
+
+	return value, nil
+}
+
+// UpdateFunc computes the new value of a key from its current value.
+// old is nil if the key does not exist.
+type UpdateFunc[T any] func(old *T) (T, error)
+
+// Update atomically reads, modifies and writes the value stored under the specified key.
+// If the key is modified concurrently, fn is called again with the fresh value, up to a bounded number of attempts.
+// Returns the value written, or an error wrapping errs.ErrConflict if all attempts conflicted.
+// Returns errs.ErrOperationNotSupported if the backend does not implement models.KVWithCAS.
+//
+// Example:
+//
+//	counter, err := client.Update(ctx, c, "counter", func(old *int) (int, error) {
+//	    if old == nil {
+//	        return 1, nil
+//	    }
+//	    return *old + 1, nil
+//	})
+func Update[T any](ctx context.Context, c Client, key string, fn UpdateFunc[T]) (T, error) {
+	var zero T
+
+	if fn == nil {
+		return zero, errs.ErrEmptyFunc
+	}
+
+	for range maxUpdateAttempts {
+		var (
+			current T
+			old     *T
+		)
+
+		// An expired value still has a version, which must be matched to overwrite it
+		version, found, err := c.getWithVersion(ctx, key, &current)
+		if err != nil {
+			return zero, err
+		}
+
+		if found {
+			old = &current
+		}
+
+		next, err := fn(old)
+		if err != nil {
+			return zero, err
+		}
+
+		_, err = c.SetIfVersion(ctx, key, next, version)
+		if errors.Is(err, errs.ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return zero, err
+		}
+
+		return next, nil
+	}
+
+	return zero, fmt.Errorf("failed to update key %s after %d attempts: %w", key, maxUpdateAttempts, errs.ErrConflict)
+}
+
+// getWithVersion retrieves and decodes the value stored under key along with its version.
+// found is false if the key does not exist or has expired; version is then the one to match to overwrite it.
+func (c Client) getWithVersion(ctx context.Context, key string, value any) (uint64, bool, error) {
+	if key == "" {
+		return 0, false, errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return 0, false, err
+	}
+
+	cas, err := c.cas()
+	if err != nil {
+		return 0, false, err
+	}
+
+	raw, version, err := cas.GetRawWithVersion(ctx, key)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	raw, err = stripTTL(raw)
+	if err != nil {
+		return version, false, nil //nolint:nilerr // expired values are reported as missing
+	}
+
+	if err := c.opts.decode(ctx, raw, value); err != nil {
+		return 0, false, err
+	}
+
+	return version, true, nil
+}
+
+// cas returns the backend as a models.KVWithCAS.
+func (c Client) cas() (models.KVWithCAS, error) {
+	cas, ok := c.KV.(models.KVWithCAS)
+	if !ok {
+		return nil, fmt.Errorf("compare-and-swap not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	return cas, nil
+}
@@ -0,0 +1,181 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func Test_SetIfVersion(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	version, err := c.SetIfAbsent(ctx, "config", "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.SetIfAbsent(ctx, "config", "v1"); !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("expected ErrConflict for an existing key, got %v", err)
+	}
+
+	var got string
+
+	current, err := c.GetWithVersion(ctx, "config", &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current != version || got != "v1" {
+		t.Errorf("GetWithVersion() = (%d, %q), want (%d, %q)", current, got, version, "v1")
+	}
+
+	newVersion, err := c.SetIfVersion(ctx, "config", "v2", version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first writer wins, the stale version conflicts
+	if _, err := c.SetIfVersion(ctx, "config", "v3", version); !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("expected ErrConflict for a stale version, got %v", err)
+	}
+
+	if err := c.DeleteIfVersion(ctx, "config", version); !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("expected ErrConflict for a stale version, got %v", err)
+	}
+
+	if err := c.DeleteIfVersion(ctx, "config", newVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetWithVersion(ctx, "config", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func Test_Update(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	increment := func(old *int) (int, error) {
+		if old == nil {
+			return 1, nil
+		}
+		return *old + 1, nil
+	}
+
+	var wg sync.WaitGroup
+
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Update(ctx, c, "counter", increment); err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var got int
+	if err := c.Get(ctx, "counter", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != 5 {
+		t.Errorf("counter = %d, want 5", got)
+	}
+}
+
+func Test_Update_RetriesOnConflict(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "config", "initial"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+
+	got, err := client.Update(ctx, c, "config", func(old *string) (string, error) {
+		calls++
+		if calls == 1 {
+			// Simulate a concurrent writer between the read and the write
+			if err := c.Set(ctx, "config", "concurrent"); err != nil {
+				return "", err
+			}
+		}
+		return *old + "+updated", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected fn to be called twice, got %d", calls)
+	}
+	if got != "concurrent+updated" {
+		t.Errorf("Update() = %q, want %q", got, "concurrent+updated")
+	}
+}
+
+func Test_Update_Errors(t *testing.T) {
+	c, err := client.New(&dummyKV{}, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	_, err = client.Update(ctx, c, "key", func(old *string) (string, error) { return "", nil })
+	if !errors.Is(err, errs.ErrOperationNotSupported) {
+		t.Errorf("expected ErrOperationNotSupported, got %v", err)
+	}
+
+	_, err = client.Update[string](ctx, c, "key", nil)
+	if !errors.Is(err, errs.ErrEmptyFunc) {
+		t.Errorf("expected ErrEmptyFunc, got %v", err)
+	}
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err = client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fnErr := errors.New("fn error")
+
+	_, err = client.Update(ctx, c, "key", func(old *string) (string, error) { return "", fnErr })
+	if !errors.Is(err, fnErr) {
+		t.Errorf("expected fn error, got %v", err)
+	}
+
+	if _, ok := mockKV.Data["key"]; ok {
+		t.Error("expected no write when fn fails")
+	}
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	mencoder "github.com/kivigo/encoders/model"
@@ -14,12 +15,46 @@ type (
 		models.KV
 		opts  Option
 		hooks *HooksRegistry
+
+		// outerHooks are the hooks of the clients this namespaced view derives from.
+		outerHooks []namespaceHooks
 	}
 
 	Options func(Option) Option
 
 	Option struct {
 		Encoder mencoder.Encoder
+
+		// TTL expires the values written by the client once elapsed.
+		// If zero, values never expire. See WithTTL.
+		TTL time.Duration
+
+		// CompensatingTxn allows Txn to run on backends that do not implement models.KVWithTxn,
+		// with a best-effort rollback. See WithCompensatingTxn.
+		CompensatingTxn bool
+
+		// SkipHooks prevents operations from triggering hooks. See WithoutHooks.
+		SkipHooks bool
+
+		// Timeout bounds the duration of each operation.
+		// If zero, only the deadline of the caller's context applies. See WithTimeout.
+		Timeout time.Duration
+
+		// KeyPrefix is prepended to the keys of each operation, and stripped from the keys returned.
+		// See WithKeyPrefix.
+		KeyPrefix string
+
+		// Transformers are applied in order to the encoded values before they are stored,
+		// and reverted in reverse order when they are read. Hooks receive the values as stored.
+		// See WithTransformers.
+		Transformers []Transformer
+
+		// KeyPolicy restricts the keys accepted by the client. See WithKeyPolicy.
+		KeyPolicy KeyPolicy
+
+		// HookDrainTimeout bounds the time Close waits for async hooks to process their pending events,
+		// before cancelling them. If zero, Close waits for all the pending events.
+		HookDrainTimeout time.Duration
 	}
 
 	HealthFunc func(ctx context.Context, c Client) error
@@ -58,12 +93,26 @@ func New(kv models.KV, opts Option) (Client, error) {
 	}, nil
 }
 
+// Close stops the async hooks of the client, waiting up to Option.HookDrainTimeout for their pending events
+// to be processed, and closes the backend.
 func (c Client) Close() error {
-	return c.KV.Close()
+	var hooksErr error
+
+	if c.hooks != nil {
+		ctx := context.Background()
+		if c.opts.HookDrainTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.opts.HookDrainTimeout)
+			defer cancel()
+		}
+		hooksErr = c.hooks.Close(ctx)
+	}
+
+	return errors.Join(hooksErr, c.KV.Close())
 }
 
 // RegisterHook registers a new hook with the client.
-// Returns a unique hook ID, an error channel for receiving hook errors,
+// Returns a unique hook ID, an error channel for receiving hook errors as *HookError,
 // and an unregister function to remove the hook.
 //
 // Example usage:
@@ -77,6 +126,52 @@ func (c Client) RegisterHook(cb HookFunc, opts HookOptions) (string, <-chan erro
 	return c.hooks.RegisterHook(cb, opts)
 }
 
+// RegisterPreHook registers a new pre-hook with the client, run before each write operation.
+// Pre-hooks can modify the key and value of the operation, or reject it when registered with
+// HookOptions.FailOperation. Conditional operations (SetIfVersion, SetIfAbsent, DeleteIfVersion and Update)
+// are rejected with errs.ErrHookRejected if a pre-hook modifies their key, as their version is bound to it.
+// Returns a unique hook ID, an error channel for receiving hook errors as *HookError,
+// and an unregister function to remove the hook.
+//
+// Example usage:
+//
+//	_, _, unregister := client.RegisterPreHook(func(ctx context.Context, evt EventType, key string, value []byte) (string, []byte, error) {
+//	    if strings.HasPrefix(key, "readonly:") {
+//	        return "", nil, errors.New("read-only key")
+//	    }
+//	    return key, value, nil
+//	}, HookOptions{Events: []EventType{EventBeforeSet, EventBeforeDelete}, FailOperation: true})
+//	defer unregister()
+func (c Client) RegisterPreHook(cb PreHookFunc, opts HookOptions) (string, <-chan error, func()) {
+	return c.hooks.RegisterPreHook(cb, opts)
+}
+
+// RegisterBatchHook registers a new batch hook with the client, called once per BatchSet or BatchDelete
+// operation with all its keys and the ID of the batch. Hooks registered with RegisterHook are still called
+// for each key, and can read the ID of the batch with BatchIDFromContext.
+// Returns a unique hook ID, an error channel for receiving hook errors as *HookError,
+// and an unregister function to remove the hook.
+//
+// Example usage:
+//
+//	_, _, unregister := client.RegisterBatchHook(func(ctx context.Context, batch client.Batch) error {
+//	    return bus.Publish(ctx, batch.ID, batch.Keys())
+//	}, HookOptions{Events: []EventType{EventBatchSet}, Async: true})
+//	defer unregister()
+func (c Client) RegisterBatchHook(cb BatchHookFunc, opts HookOptions) (string, <-chan error, func()) {
+	return c.hooks.RegisterBatchHook(cb, opts)
+}
+
+// HookStats returns the counters of the hook with the given ID. Returns false if no such hook is registered.
+//
+// Example usage:
+//
+//	stats, _ := client.HookStats(hookID)
+//	log.Printf("hook errors: %d, dropped events: %d", stats.Errors, stats.DroppedEvents)
+func (c Client) HookStats(id string) (HookStats, bool) {
+	return c.hooks.Stats(id)
+}
+
 // UnregisterHook removes a hook by its ID.
 //
 // Example usage:
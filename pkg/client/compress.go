@@ -0,0 +1,345 @@
+package client
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+// compressionEnvelopeMagic prefixes the values compressed by the compression transformer.
+// It is followed by the ID of the compressor, then the compressed value.
+var compressionEnvelopeMagic = []byte{0x00, 'k', 'v', 'g', ':', 'c', 'm', 'p'}
+
+// DefaultMaxDecompressedSize is the maximum size of the values decompressed by the transformer returned by Compress.
+const DefaultMaxDecompressedSize = 64 << 20
+
+// Compressor IDs identify the compressor of the values stored by the compression transformer.
+const (
+	// compressorStored marks uncompressed values starting with compressionEnvelopeMagic.
+	compressorStored byte = 0x00
+	// compressorGzip identifies the values compressed by Gzip.
+	compressorGzip byte = 0x01
+	// compressorDeflate identifies the values compressed by Deflate.
+	compressorDeflate byte = 0x02
+	// compressorSnappy identifies the values compressed by Snappy.
+	compressorSnappy byte = 0x03
+	// compressorZstd identifies the values compressed by Zstd.
+	compressorZstd byte = 0x04
+
+	// compressorCustom is the first ID available to custom compressors.
+	compressorCustom byte = 0x80
+)
+
+type (
+	// Compressor compresses values for the compression transformer.
+	//
+	// Custom compressors must use an ID of at least 0x80 that identifies them in the stored values.
+	Compressor interface {
+		// ID returns the byte identifying the values compressed by this compressor.
+		ID() byte
+
+		Compress(value []byte) ([]byte, error)
+
+		// Decompress decompresses the value, or returns an error wrapping errs.ErrValueTooLarge
+		// if the decompressed value is larger than maxSize bytes.
+		Decompress(value []byte, maxSize int) ([]byte, error)
+	}
+
+	// compression is a Transformer compressing the values larger than minSize.
+	compression struct {
+		compressor  Compressor
+		minSize     int
+		maxSize     int
+		compressors map[byte]Compressor
+	}
+
+	// gzipCompressor implements Compressor with compress/gzip.
+	gzipCompressor struct {
+		level int
+	}
+
+	// deflateCompressor implements Compressor with compress/flate.
+	deflateCompressor struct {
+		level int
+	}
+
+	// snappyCompressor implements Compressor with the snappy block format.
+	snappyCompressor struct{}
+
+	// zstdCompressor implements Compressor with zstd.
+	zstdCompressor struct {
+		encoder *zstd.Encoder
+		err     error
+	}
+)
+
+// Compress returns a Transformer compressing the values of at least minSize bytes with compressor.
+// Smaller values are stored uncompressed, as compressing them rarely saves space.
+// Values larger than DefaultMaxDecompressedSize once decompressed are rejected, see CompressLimit.
+//
+// Compressed values are stored in an envelope identifying the compressor, so that values compressed
+// by Gzip, Deflate, Snappy or Zstd are always decompressed, whichever compressor is used to write new values.
+// Values stored before compression was enabled are read as is.
+//
+// Example:
+//
+//	client, err := kivigo.New(backend, client.WithTransformers(client.Compress(client.Zstd(), 1024)))
+func Compress(compressor Compressor, minSize int) Transformer {
+	return CompressLimit(compressor, minSize, DefaultMaxDecompressedSize)
+}
+
+// CompressLimit returns a Transformer like Compress, rejecting the values larger than maxSize bytes
+// once decompressed with errs.ErrValueTooLarge, so that a corrupt or malicious value cannot exhaust the memory.
+//
+// Example:
+//
+//	client.WithTransformers(client.CompressLimit(client.Gzip(), 1024, 1<<20))
+func CompressLimit(compressor Compressor, minSize, maxSize int) Transformer {
+	c := &compression{
+		compressor: compressor,
+		minSize:    minSize,
+		maxSize:    maxSize,
+		compressors: map[byte]Compressor{
+			compressorGzip:    Gzip(),
+			compressorDeflate: Deflate(),
+			compressorSnappy:  Snappy(),
+			compressorZstd:    &zstdCompressor{}, // Only decompresses
+		},
+	}
+
+	if id := compressor.ID(); id >= compressorCustom {
+		c.compressors[id] = compressor
+	}
+
+	return c
+}
+
+// Gzip returns a Compressor using gzip with the default compression level.
+func Gzip() Compressor {
+	return gzipCompressor{level: gzip.DefaultCompression}
+}
+
+// GzipLevel returns a Compressor using gzip with the given compression level, e.g. gzip.BestSpeed.
+func GzipLevel(level int) Compressor {
+	return gzipCompressor{level: level}
+}
+
+// Deflate returns a Compressor using raw deflate with the default compression level.
+// It is faster and smaller than Gzip, which adds a header and a checksum to the deflate stream.
+func Deflate() Compressor {
+	return deflateCompressor{level: flate.DefaultCompression}
+}
+
+// DeflateLevel returns a Compressor using raw deflate with the given compression level, e.g. flate.BestSpeed.
+func DeflateLevel(level int) Compressor {
+	return deflateCompressor{level: level}
+}
+
+// Snappy returns a Compressor using the snappy block format.
+// It compresses less than Gzip and Deflate but is much faster, which suits latency-sensitive workloads.
+func Snappy() Compressor {
+	return snappyCompressor{}
+}
+
+// Zstd returns a Compressor using zstd with the default compression level.
+// It compresses about as well as Gzip at a much higher speed.
+func Zstd() Compressor {
+	return ZstdLevel(3)
+}
+
+// ZstdLevel returns a Compressor using zstd with the given compression level, from 1 (fastest) to 22 (best).
+func ZstdLevel(level int) Compressor {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+
+	return &zstdCompressor{encoder: encoder, err: err}
+}
+
+func (c *compression) Apply(_ context.Context, value []byte) ([]byte, error) {
+	if len(value) < c.minSize {
+		if bytes.HasPrefix(value, compressionEnvelopeMagic) {
+			return compressionEnvelope(compressorStored, value), nil
+		}
+
+		return value, nil
+	}
+
+	id := c.compressor.ID()
+	if id < compressorCustom && !isBuiltinCompressor(c.compressor) {
+		return nil, fmt.Errorf("invalid compressor ID %#x: custom compressors must use an ID of at least %#x", id, compressorCustom)
+	}
+
+	compressed, err := c.compressor.Compress(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress value: %w", err)
+	}
+
+	return compressionEnvelope(id, compressed), nil
+}
+
+func (c *compression) Revert(_ context.Context, value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, compressionEnvelopeMagic) {
+		return value, nil
+	}
+
+	rest := value[len(compressionEnvelopeMagic):]
+	if len(rest) == 0 {
+		return nil, errors.New("truncated compression envelope")
+	}
+
+	if rest[0] == compressorStored {
+		return rest[1:], nil
+	}
+
+	compressor, ok := c.compressors[rest[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown compressor ID %#x", rest[0])
+	}
+
+	decompressed, err := compressor.Decompress(rest[1:], c.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress value: %w", err)
+	}
+
+	return decompressed, nil
+}
+
+// compressionEnvelope returns the value prefixed with the compression envelope of the compressor.
+func compressionEnvelope(id byte, value []byte) []byte {
+	raw := make([]byte, 0, len(compressionEnvelopeMagic)+1+len(value))
+	raw = append(raw, compressionEnvelopeMagic...)
+	raw = append(raw, id)
+
+	return append(raw, value...)
+}
+
+func (gzipCompressor) ID() byte { return compressorGzip }
+
+func (g gzipCompressor) Compress(value []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&buf, g.level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(value []byte, maxSize int) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return readLimited(r, maxSize)
+}
+
+func (deflateCompressor) ID() byte { return compressorDeflate }
+
+func (d deflateCompressor) Compress(value []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := flate.NewWriter(&buf, d.level)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(value); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (deflateCompressor) Decompress(value []byte, maxSize int) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(value))
+	defer r.Close()
+
+	return readLimited(r, maxSize)
+}
+
+func (snappyCompressor) ID() byte { return compressorSnappy }
+
+func (snappyCompressor) Compress(value []byte) ([]byte, error) {
+	return snappy.Encode(nil, value), nil
+}
+
+func (snappyCompressor) Decompress(value []byte, maxSize int) ([]byte, error) {
+	// The snappy block format announces the decompressed size
+	size, err := snappy.DecodedLen(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > maxSize {
+		return nil, fmt.Errorf("%w: decompressed value of %d bytes exceeds %d bytes", errs.ErrValueTooLarge, size, maxSize)
+	}
+
+	return snappy.Decode(nil, value)
+}
+
+func (*zstdCompressor) ID() byte { return compressorZstd }
+
+func (z *zstdCompressor) Compress(value []byte) ([]byte, error) {
+	if z.err != nil {
+		return nil, z.err
+	}
+
+	return z.encoder.EncodeAll(value, nil), nil
+}
+
+func (*zstdCompressor) Decompress(value []byte, maxSize int) ([]byte, error) {
+	r, err := zstd.NewReader(bytes.NewReader(value), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return readLimited(r, maxSize)
+}
+
+// isBuiltinCompressor reports whether the compressor is one of the builtin compressors.
+func isBuiltinCompressor(c Compressor) bool {
+	switch c.(type) {
+	case gzipCompressor, deflateCompressor, snappyCompressor, *zstdCompressor:
+		return true
+	default:
+		return false
+	}
+}
+
+// readLimited reads r until EOF, or returns an error wrapping errs.ErrValueTooLarge once more than maxSize bytes are read.
+func readLimited(r io.Reader, maxSize int) ([]byte, error) {
+	value, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(value) > maxSize {
+		return nil, fmt.Errorf("%w: decompressed value exceeds %d bytes", errs.ErrValueTooLarge, maxSize)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,275 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func TestCompress_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	values := map[string][]byte{
+		"empty":      {},
+		"short":      []byte("abc"),
+		"repetitive": bytes.Repeat([]byte("kivigo "), 10000),
+		"reserved":   append(slices.Clone(compressionEnvelopeMagic), bytes.Repeat([]byte("x"), 100)...),
+		"msgpack":    {0xFF, 0xF6, 0xE0, 0x01},
+		"long":       []byte(strings.Repeat(`{"id":42,"name":"alice","tags":["a","b"]}`, 500)),
+	}
+
+	for name, compressor := range map[string]Compressor{
+		"gzip":    Gzip(),
+		"deflate": Deflate(),
+		"snappy":  Snappy(),
+		"zstd":    Zstd(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			transformer := Compress(compressor, 16)
+
+			for vName, value := range values {
+				stored, err := transformer.Apply(ctx, value)
+				if err != nil {
+					t.Fatalf("%s: %v", vName, err)
+				}
+
+				got, err := transformer.Revert(ctx, stored)
+				if err != nil {
+					t.Fatalf("%s: %v", vName, err)
+				}
+
+				if !bytes.Equal(got, value) {
+					t.Errorf("%s: round trip mismatch", vName)
+				}
+			}
+
+			stored, _ := transformer.Apply(ctx, values["repetitive"])
+			if !bytes.Equal(stored[:len(compressionEnvelopeMagic)+1], compressionEnvelope(compressor.ID(), nil)) || len(stored) >= len(values["repetitive"])/10 {
+				t.Errorf("expected the value to be compressed, got %d bytes", len(stored))
+			}
+		})
+	}
+}
+
+func TestCompress_Threshold(t *testing.T) {
+	ctx := context.Background()
+	transformer := Compress(Gzip(), 1024)
+
+	stored, err := transformer.Apply(ctx, []byte(`"small"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored) != `"small"` {
+		t.Errorf("expected small values to be stored as is, got %q", stored)
+	}
+
+	// Binary values are stored as is, whatever their first byte
+	stored, err = transformer.Apply(ctx, []byte{0xF6, 'x'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, []byte{0xF6, 'x'}) {
+		t.Errorf("unexpected stored value %v", stored)
+	}
+
+	// Small values starting with the envelope magic are marked as stored so that they are not mistaken for compressed ones
+	value := compressionEnvelope(compressorGzip, []byte("x"))
+
+	stored, err = transformer.Apply(ctx, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, compressionEnvelope(compressorStored, value)) {
+		t.Errorf("unexpected stored value %v", stored)
+	}
+	if got, err := transformer.Revert(ctx, stored); err != nil || !bytes.Equal(got, value) {
+		t.Errorf("Revert() = (%v, %v), want %v", got, err, value)
+	}
+}
+
+func TestCompress_MaxDecompressedSize(t *testing.T) {
+	ctx := context.Background()
+	value := bytes.Repeat([]byte{'x'}, 1<<20)
+
+	for name, compressor := range map[string]Compressor{
+		"gzip":    Gzip(),
+		"deflate": Deflate(),
+		"snappy":  Snappy(),
+		"zstd":    Zstd(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			stored, err := Compress(compressor, 0).Apply(ctx, value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := CompressLimit(compressor, 0, len(value)-1).Revert(ctx, stored); !errors.Is(err, errs.ErrValueTooLarge) {
+				t.Errorf("expected ErrValueTooLarge, got %v", err)
+			}
+
+			if got, err := CompressLimit(compressor, 0, len(value)).Revert(ctx, stored); err != nil || !bytes.Equal(got, value) {
+				t.Errorf("expected the value to be decompressed up to the limit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCompress_CustomCompressorID(t *testing.T) {
+	if _, err := Compress(customCompressor{id: compressorGzip}, 0).Apply(context.Background(), []byte("x")); err == nil {
+		t.Error("expected an error for a custom compressor using a builtin ID")
+	}
+
+	transformer := Compress(customCompressor{id: 0x80}, 0)
+
+	stored, err := transformer.Apply(context.Background(), []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := transformer.Revert(context.Background(), stored); err != nil || string(got) != "x" {
+		t.Errorf("Revert() = (%q, %v), want %q", got, err, "x")
+	}
+}
+
+// customCompressor stores values as is under the given ID.
+type customCompressor struct {
+	id byte
+}
+
+func (c customCompressor) ID() byte { return c.id }
+
+func (customCompressor) Compress(value []byte) ([]byte, error) { return value, nil }
+
+func (customCompressor) Decompress(value []byte, _ int) ([]byte, error) { return value, nil }
+
+func TestCompress_ReadsOtherCompressors(t *testing.T) {
+	ctx := context.Background()
+	value := bytes.Repeat([]byte("abc"), 100)
+
+	stored, err := Compress(Snappy(), 0).Apply(ctx, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Compress(Gzip(), 0).Revert(ctx, stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, value) {
+		t.Error("expected values compressed by another builtin compressor to be decompressed")
+	}
+
+	if _, err := Compress(Gzip(), 0).Revert(ctx, compressionEnvelope(0x90, []byte{1, 2})); err == nil {
+		t.Error("expected an error for an unknown compression header")
+	}
+}
+
+func TestSnappy_ReferenceEncodings(t *testing.T) {
+	// Encodings produced by the reference implementation github.com/golang/snappy
+	for value, encoded := range map[string][]byte{
+		"":            {0x00},
+		"a":           {0x01, 0x00, 'a'},
+		"abcdabcdabc": {0x0b, 0x28, 'a', 'b', 'c', 'd', 'a', 'b', 'c', 'd', 'a', 'b', 'c'},
+		strings.Repeat("kivigo ", 20): {
+			0x8c, 0x01, 0x18, 'k', 'i', 'v', 'i', 'g', 'o', ' ', 0xfe, 0x07, 0x00, 0xfe, 0x07, 0x00, 0x05, 0x07,
+		},
+	} {
+		got, err := Snappy().Decompress(encoded, len(value))
+		if err != nil || string(got) != value {
+			t.Errorf("Decompress(%v) = (%q, %v), want %q", encoded, got, err, value)
+		}
+
+		// Encoders may choose other literals and copies, but must produce blocks that decode to the same value
+		compressed, err := Snappy().Compress([]byte(value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := Snappy().Decompress(compressed, len(value)); err != nil || string(got) != value {
+			t.Errorf("Decompress(Compress(%q)) = (%q, %v)", value, got, err)
+		}
+	}
+
+	// "abcdabcdabc" as a 4-byte literal followed by a 7-byte copy at offset 4, as emitted by other encoders
+	got, err := Snappy().Decompress([]byte{0x0b, 0x0c, 'a', 'b', 'c', 'd', 0x0d, 0x04}, 11)
+	if err != nil || string(got) != "abcdabcdabc" {
+		t.Errorf("Decompress() = (%q, %v), want %q", got, err, "abcdabcdabc")
+	}
+
+	for _, corrupt := range [][]byte{
+		{},
+		{0x05, 0x10, 'a'},             // literal longer than the input
+		{0x08, 0x00, 'a', 0x0d, 0x04}, // copy before its offset
+		{0x03, 0x04, 'a', 'b'},        // decoded length mismatch
+	} {
+		if _, err := Snappy().Decompress(corrupt, 100); err == nil {
+			t.Errorf("expected an error for corrupt input %v", corrupt)
+		}
+	}
+}
+
+func TestClient_WithTransformers(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	plain, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// Values stored before compression was enabled remain readable
+	if err := plain.Set(ctx, "legacy", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	o := WithTransformers(Compress(Gzip(), 64))(Option{Encoder: json.New()})
+
+	c, err := New(mockKV, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	large := strings.Repeat("compressible ", 100)
+
+	if err := c.Set(ctx, "large", large); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(mockKV.Data["large"], compressionEnvelope(compressorGzip, nil)) {
+		t.Errorf("expected the stored value to be compressed")
+	}
+
+	if err := c.BatchSet(ctx, map[string]any{"batch": large}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	for _, k := range []string{"large", "batch", "legacy"} {
+		if err := c.Get(ctx, k, &got); err != nil {
+			t.Fatalf("%s: %v", k, err)
+		}
+	}
+
+	values := map[string]string{}
+	if err := c.BatchGet(ctx, []string{"large", "batch"}, values); err != nil {
+		t.Fatal(err)
+	}
+	if values["large"] != large || values["batch"] != large {
+		t.Error("unexpected values returned by BatchGet")
+	}
+
+	for entry, err := range c.Iterate(ctx, IterateOptions{Prefix: "large"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Decode(ctx, &got); err != nil || got != large {
+			t.Errorf("Decode() = (%q, %v)", got, err)
+		}
+	}
+}
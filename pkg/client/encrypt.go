@@ -0,0 +1,289 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// encryptionEnvelopeMagic prefixes the values encrypted by the encryption transformer.
+var encryptionEnvelopeMagic = []byte{0x00, 'k', 'v', 'g', ':', 'e', 'n', 'c'}
+
+// encryptionEnvelopeVersion is the version of the envelope written by the encryption transformer:
+// magic, version, key ID length, key ID, nonce, then the sealed value.
+const encryptionEnvelopeVersion byte = 1
+
+type (
+	// KeyProvider provides the keys used by the encryption transformer.
+	// Keys must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+	KeyProvider interface {
+		// CurrentKey returns the ID and the key used to encrypt new values.
+		CurrentKey(ctx context.Context) (string, []byte, error)
+
+		// Key returns the key with the given ID, or errs.ErrUnknownKeyID if there is none.
+		Key(ctx context.Context, id string) ([]byte, error)
+	}
+
+	// StaticKeys is a KeyProvider holding its keys in memory.
+	StaticKeys struct {
+		// Current is the ID of the key used to encrypt new values.
+		Current string
+
+		// Keys maps the key IDs to the keys. Retired keys must be kept until RotateKeys has re-encrypted
+		// the values they protect.
+		Keys map[string][]byte
+	}
+
+	// EncryptOption configures the encryption transformer returned by Encrypt.
+	EncryptOption func(*encryption)
+
+	// encryption is a Transformer encrypting the values with AES-GCM.
+	encryption struct {
+		keys           KeyProvider
+		allowPlaintext bool
+	}
+)
+
+// Encrypt returns a Transformer encrypting the values with AES-GCM using the keys of the provider.
+//
+// Each value is stored in a versioned envelope carrying the ID of the key that encrypted it, so that keys can be
+// rotated: new values are encrypted with the current key while older values remain readable as long as their key
+// is provided. Values that are not encrypted are rejected with errs.ErrNotEncrypted, unless AllowPlaintext is given
+// to read the values stored before encryption was enabled until RotateKeys has encrypted them.
+//
+// Encryption should be the last transformer, as encrypted values do not compress.
+//
+// Example:
+//
+//	keys := client.StaticKeys{Current: "2025-01", Keys: map[string][]byte{"2025-01": key}}
+//	client, err := kivigo.New(backend, client.WithTransformers(client.Compress(client.Gzip(), 1024), client.Encrypt(keys)))
+func Encrypt(keys KeyProvider, opts ...EncryptOption) Transformer {
+	e := &encryption{keys: keys}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// AllowPlaintext makes the encryption transformer read the values that are not encrypted as is,
+// instead of rejecting them with errs.ErrNotEncrypted. It eases enabling encryption on existing data,
+// but lets anyone with write access to the backend inject values, so it should only be used until
+// RotateKeys has encrypted the existing values.
+//
+// Example:
+//
+//	client.WithTransformers(client.Encrypt(keys, client.AllowPlaintext()))
+func AllowPlaintext() EncryptOption {
+	return func(e *encryption) {
+		e.allowPlaintext = true
+	}
+}
+
+// CurrentKey implements KeyProvider.
+func (s StaticKeys) CurrentKey(ctx context.Context) (string, []byte, error) {
+	key, err := s.Key(ctx, s.Current)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.Current, key, nil
+}
+
+// Key implements KeyProvider.
+func (s StaticKeys) Key(_ context.Context, id string) ([]byte, error) {
+	key, ok := s.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key %q: %w", id, errs.ErrUnknownKeyID)
+	}
+
+	return key, nil
+}
+
+func (e *encryption) Apply(ctx context.Context, value []byte) ([]byte, error) {
+	id, key, err := e.keys.CurrentKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current encryption key: %w", err)
+	}
+
+	if len(id) > math.MaxUint8 {
+		return nil, fmt.Errorf("encryption key ID %q is longer than %d bytes", id, math.MaxUint8)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+	}
+
+	header := make([]byte, 0, len(encryptionEnvelopeMagic)+2+len(id))
+	header = append(header, encryptionEnvelopeMagic...)
+	header = append(header, encryptionEnvelopeVersion, byte(len(id)))
+	header = append(header, id...)
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The header is authenticated so that the key ID cannot be tampered with
+	raw := append(header, nonce...)
+
+	return aead.Seal(raw, nonce, value, header), nil
+}
+
+func (e *encryption) Revert(ctx context.Context, value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, encryptionEnvelopeMagic) {
+		if e.allowPlaintext {
+			return value, nil
+		}
+
+		return nil, errs.ErrNotEncrypted
+	}
+
+	rest := value[len(encryptionEnvelopeMagic):]
+	if len(rest) < 2 {
+		return nil, errors.New("truncated encryption envelope")
+	}
+
+	if rest[0] != encryptionEnvelopeVersion {
+		return nil, fmt.Errorf("unsupported encryption envelope version %d", rest[0])
+	}
+
+	idLen := int(rest[1])
+	if len(rest) < 2+idLen {
+		return nil, errors.New("truncated encryption envelope")
+	}
+
+	id := string(rest[2 : 2+idLen])
+	headerLen := len(encryptionEnvelopeMagic) + 2 + idLen
+
+	key, err := e.keys.Key(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encryption key %q: %w", id, err)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+	}
+
+	if len(value) < headerLen+aead.NonceSize() {
+		return nil, errors.New("truncated encryption envelope")
+	}
+
+	nonce := value[headerLen : headerLen+aead.NonceSize()]
+
+	plain, err := aead.Open(nil, nonce, value[headerLen+aead.NonceSize():], value[:headerLen])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value with key %q: %w", id, err)
+	}
+
+	return plain, nil
+}
+
+// RotateKeys rewrites the values of the keys starting with prefix with the current transformers,
+// so that they are encrypted with the current key of the encryption transformer. Values stored before
+// encryption was enabled are encrypted as well if the transformer allows them, see AllowPlaintext.
+// Expirations are preserved. Pre-hooks are not called, but EventSetRaw is triggered with the rewritten
+// value of each key, so that caches attached to the client are invalidated.
+//
+// Values written concurrently may be overwritten with their previous content, so RotateKeys should run
+// while the keys are not being written.
+//
+// Example:
+//
+//	keys.Current = "2025-02"
+//	if err := client.RotateKeys(ctx, "user:"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (c Client) RotateKeys(ctx context.Context, prefix string) error {
+	keys, err := c.KV.List(ctx, c.opts.key(prefix))
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := c.rotateKey(ctx, key); err != nil {
+			return fmt.Errorf("failed to rotate key %s: %w", c.opts.unprefixed(key), err)
+		}
+	}
+
+	return nil
+}
+
+// rotateKey rewrites the value of key with the current transformers and triggers EventSetRaw.
+func (c Client) rotateKey(ctx context.Context, key string) error {
+	value, ok, err := c.reencrypt(ctx, key)
+	if err != nil || !ok {
+		return err
+	}
+
+	c.runHooks(ctx, c.opts, EventSetRaw, key, value)
+
+	return nil
+}
+
+// reencrypt rewrites the value of key with the current transformers and returns the value as stored.
+// It returns false if the key is missing or expired.
+func (c Client) reencrypt(ctx context.Context, key string) ([]byte, bool, error) {
+	raw, err := c.KV.GetRaw(ctx, key)
+	if errors.Is(err, errs.ErrNotFound) {
+		// Deleted since it was listed
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, expiresAt, wrapped := unwrapTTL(raw)
+	if wrapped && !expiresAt.After(now()) {
+		return nil, false, nil
+	}
+
+	value, err = c.opts.untransform(ctx, value)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, err = c.opts.transform(ctx, value)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if wrapped {
+		value = wrapTTL(value, expiresAt)
+
+		return value, true, c.KV.SetRaw(ctx, key, value)
+	}
+
+	if kv, ok := c.KV.(models.KVWithTTL); ok {
+		ttl, err := kv.TTL(ctx, key)
+		if err != nil && !errors.Is(err, errs.ErrOperationNotSupported) {
+			return nil, false, err
+		}
+
+		if ttl > 0 {
+			return value, true, kv.SetRawWithTTL(ctx, key, value, ttl)
+		}
+	}
+
+	return value, true, c.KV.SetRaw(ctx, key, value)
+}
+
+// newAEAD returns an AES-GCM cipher using key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keys := StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	transformer := Encrypt(keys)
+
+	stored, err := transformer.Apply(ctx, []byte(`"secret"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored, []byte("secret")) {
+		t.Error("expected the stored value to be encrypted")
+	}
+
+	got, err := transformer.Revert(ctx, stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `"secret"` {
+		t.Errorf("Revert() = %q, want %q", got, `"secret"`)
+	}
+
+	// Plain values are rejected unless allowed
+	if _, err := transformer.Revert(ctx, []byte(`"plain"`)); !errors.Is(err, errs.ErrNotEncrypted) {
+		t.Errorf("expected ErrNotEncrypted, got %v", err)
+	}
+
+	got, err = Encrypt(keys, AllowPlaintext()).Revert(ctx, []byte(`"plain"`))
+	if err != nil || string(got) != `"plain"` {
+		t.Errorf("Revert() = (%q, %v), want the plain value", got, err)
+	}
+
+	// Tampering with the key ID or the ciphertext is detected
+	tampered := bytes.Clone(stored)
+	tampered[len(tampered)-1] ^= 0xFF
+	if _, err := transformer.Revert(ctx, tampered); err == nil {
+		t.Error("expected an error for a tampered value")
+	}
+}
+
+func TestEncrypt_Errors(t *testing.T) {
+	ctx := context.Background()
+	old := StaticKeys{Current: "old", Keys: map[string][]byte{"old": bytes.Repeat([]byte{1}, 16)}}
+
+	stored, err := Encrypt(old).Apply(ctx, []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := StaticKeys{Current: "new", Keys: map[string][]byte{"new": bytes.Repeat([]byte{2}, 16)}}
+
+	_, err = Encrypt(current).Revert(ctx, stored)
+	if !errors.Is(err, errs.ErrUnknownKeyID) {
+		t.Errorf("expected ErrUnknownKeyID, got %v", err)
+	}
+
+	if _, err := Encrypt(StaticKeys{Current: "missing"}).Apply(ctx, []byte("value")); !errors.Is(err, errs.ErrUnknownKeyID) {
+		t.Errorf("expected ErrUnknownKeyID, got %v", err)
+	}
+
+	invalid := StaticKeys{Current: "short", Keys: map[string][]byte{"short": []byte("too short")}}
+	if _, err := Encrypt(invalid).Apply(ctx, []byte("value")); err == nil {
+		t.Error("expected an error for an invalid key size")
+	}
+
+	if _, err := Encrypt(old).Revert(ctx, stored[:len(encryptionEnvelopeMagic)+1]); err == nil {
+		t.Error("expected an error for a truncated envelope")
+	}
+}
+
+func TestClient_RotateKeys(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	setClock(t, &current)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	ctx := context.Background()
+
+	plain, err := New(basicKV{mockKV}, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := plain.Set(ctx, "user:legacy", "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &StaticKeys{Current: "k1", Keys: map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 32),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	}}
+
+	c, err := New(basicKV{mockKV}, WithTransformers(Encrypt(keys, AllowPlaintext()))(Option{Encoder: json.New()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rewritten []string
+
+	_, _, unregister := c.RegisterHook(func(_ context.Context, _ EventType, key string, _ []byte) error {
+		rewritten = append(rewritten, key)
+		return nil
+	}, HookOptions{Events: []EventType{EventSetRaw}})
+	defer unregister()
+
+	if err := c.Set(ctx, "user:expiring", "bob", WithTTL(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	keys.Current = "k2"
+
+	if err := c.RotateKeys(ctx, "user:"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every value is now readable without the retired key
+	delete(keys.Keys, "k1")
+
+	var got string
+	for key, want := range map[string]string{"user:legacy": "alice", "user:expiring": "bob"} {
+		if err := c.Get(ctx, key, &got); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		if got != want {
+			t.Errorf("%s: Get() = %q, want %q", key, got, want)
+		}
+	}
+
+	slices.Sort(rewritten)
+	if !slices.Equal(rewritten, []string{"user:expiring", "user:legacy"}) {
+		t.Errorf("expected EventSetRaw for every rewritten key, got %v", rewritten)
+	}
+
+	if bytes.Contains(mockKV.Data["user:legacy"], []byte("alice")) {
+		t.Error("expected the legacy value to be encrypted")
+	}
+
+	ttl, err := c.TTL(ctx, "user:expiring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl != time.Hour {
+		t.Errorf("expected the expiration to be preserved, got %v", ttl)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"slices"
+)
+
+type (
+	// BatchEntry is a key written by a batch operation, with its value as stored, or nil for deletions.
+	BatchEntry struct {
+		Key   string
+		Value []byte
+	}
+
+	// Batch is the set of keys written by a single BatchSet or BatchDelete operation.
+	Batch struct {
+		// ID identifies the batch. Per-key hooks triggered by the batch can read it with BatchIDFromContext.
+		ID string
+
+		// Event is EventBatchSet or EventBatchDel.
+		Event EventType
+
+		// Entries are the keys of the batch, sorted.
+		Entries []BatchEntry
+	}
+
+	// BatchHookFunc is the function signature for batch hooks, called once per batch operation.
+	BatchHookFunc func(ctx context.Context, batch Batch) error
+)
+
+// batchIDKey is the context key under which the ID of a batch is stored while its hooks run.
+type batchIDKey struct{}
+
+// BatchIDFromContext returns the ID of the batch the event being dispatched to a hook belongs to.
+// The second return value is false if the event was not triggered by a batch operation.
+func BatchIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(batchIDKey{}).(string)
+	return id, ok
+}
+
+// Keys returns the keys of the batch.
+func (b Batch) Keys() []string {
+	keys := make([]string, len(b.Entries))
+	for i, e := range b.Entries {
+		keys[i] = e.Key
+	}
+
+	return keys
+}
+
+// filter returns the batch restricted to the keys matched by f.
+func (b Batch) filter(f HookFilterFunc) Batch {
+	if f == nil {
+		return b
+	}
+
+	b.Entries = slices.DeleteFunc(slices.Clone(b.Entries), func(e BatchEntry) bool {
+		return !f(e.Key)
+	})
+
+	return b
+}
+
+// withPrefix returns the batch with prefix prepended to its keys.
+func (b Batch) withPrefix(prefix string) Batch {
+	entries := make([]BatchEntry, len(b.Entries))
+	for i, e := range b.Entries {
+		entries[i] = BatchEntry{Key: prefix + e.Key, Value: e.Value}
+	}
+	b.Entries = entries
+
+	return b
+}
+
+// RegisterBatchHook registers a new batch hook with the given callback and options.
+// Batch hooks are called once per BatchSet or BatchDelete operation with all its keys, whereas hooks
+// registered with RegisterHook are called for each key. The Filter option restricts the keys of the batch,
+// and the hook is not called if none of them matches.
+// Returns a unique hook ID, an error channel for receiving hook errors as *HookError,
+// and an unregister function to remove the hook.
+func (hr *HooksRegistry) RegisterBatchHook(cb BatchHookFunc, opts HookOptions) (string, <-chan error, func()) {
+	return hr.register(&hookRegistration{batch: cb, options: opts})
+}
+
+// RunBatch executes all registered batch hooks that match the event of the batch and at least one of its keys,
+// by decreasing priority, then in registration order.
+// This method is called internally after successful batch operations.
+func (hr *HooksRegistry) RunBatch(ctx context.Context, batch Batch) {
+	ctx = context.WithValue(ctx, batchIDKey{}, batch.ID)
+
+	for _, registration := range hr.snapshot(hookKindBatch) {
+		if !registration.handles(batch.Event) {
+			continue
+		}
+
+		b := batch.filter(registration.options.Filter)
+		if len(b.Entries) == 0 {
+			continue
+		}
+
+		if registration.pool != nil {
+			registration.pool.enqueue(ctx, &hookTask{ctx: context.WithoutCancel(ctx), evt: b.Event, key: b.ID, batch: &b})
+		} else {
+			hr.executeBatchHook(ctx, registration, b)
+		}
+	}
+}
+
+// executeBatchHook executes a batch hook with its timeout and retry policy, and reports its error if any.
+func (hr *HooksRegistry) executeBatchHook(ctx context.Context, registration *hookRegistration, batch Batch) {
+	attempt, err := registration.call(ctx, func(ctx context.Context) error {
+		return registration.batch(ctx, batch)
+	})
+	if err != nil {
+		registration.report(&HookError{
+			HookID: registration.id, Event: batch.Event, BatchID: batch.ID, Attempt: attempt, Err: err,
+		})
+	}
+}
+
+// newBatch returns a batch of the given event with the keys and values, sorted by key.
+func newBatch(evt EventType, keys []string, values map[string][]byte) Batch {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+
+	entries := make([]BatchEntry, len(keys))
+	for i, k := range keys {
+		entries[i] = BatchEntry{Key: k, Value: values[k]}
+	}
+
+	return Batch{ID: generateID(), Event: evt, Entries: entries}
+}
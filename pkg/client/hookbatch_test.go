@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func TestClient_BatchHooks(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var batches []Batch
+
+	_, _, unregister := c.RegisterBatchHook(func(_ context.Context, batch Batch) error {
+		batches = append(batches, batch)
+		return nil
+	}, HookOptions{})
+	defer unregister()
+
+	var perKey []string
+
+	_, _, unregisterPerKey := c.RegisterHook(func(ctx context.Context, evt EventType, key string, _ []byte) error {
+		id, ok := BatchIDFromContext(ctx)
+		if !ok {
+			id = "-"
+		}
+		perKey = append(perKey, string(evt)+" "+key+" "+id)
+		return nil
+	}, HookOptions{})
+	defer unregisterPerKey()
+
+	ctx := context.Background()
+
+	if err := c.BatchSet(ctx, map[string]any{"b": "2", "a": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BatchDelete(ctx, []string{"b", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "c", "3"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batches) != 2 {
+		t.Fatalf("expected one call per batch, got %d", len(batches))
+	}
+
+	set, del := batches[0], batches[1]
+	if set.Event != EventBatchSet || !slices.Equal(set.Keys(), []string{"a", "b"}) || string(set.Entries[1].Value) != `"2"` {
+		t.Errorf("unexpected batch %+v", set)
+	}
+	if del.Event != EventBatchDel || !slices.Equal(del.Keys(), []string{"a", "b"}) || del.Entries[0].Value != nil {
+		t.Errorf("unexpected batch %+v", del)
+	}
+	if set.ID == "" || set.ID == del.ID {
+		t.Errorf("expected distinct batch IDs, got %q and %q", set.ID, del.ID)
+	}
+
+	want := []string{
+		"BATCH_SET a " + set.ID,
+		"BATCH_SET b " + set.ID,
+		"BATCH_DELETE a " + del.ID,
+		"BATCH_DELETE b " + del.ID,
+		"SET c -",
+	}
+	if !slices.Equal(perKey, want) {
+		t.Errorf("got per-key events %v, want %v", perKey, want)
+	}
+}
+
+func TestClient_BatchHookOptions(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu      sync.Mutex
+		batches []Batch
+	)
+
+	_, _, unregister := c.RegisterBatchHook(func(_ context.Context, batch Batch) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, batch)
+		return nil
+	}, HookOptions{Events: []EventType{EventBatchSet}, Filter: PrefixFilter("user:"), Async: true})
+	defer unregister()
+
+	cause := errors.New("bus unavailable")
+	id, errCh, unregisterFailing := c.RegisterBatchHook(func(_ context.Context, _ Batch) error {
+		return cause
+	}, HookOptions{Events: []EventType{EventBatchDel}})
+	defer unregisterFailing()
+
+	ctx := context.Background()
+
+	if err := c.BatchSet(ctx, map[string]any{"user:1": "a", "user:2": "b", "order:1": "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BatchSet(ctx, map[string]any{"order:2": "d"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BatchDelete(ctx, []string{"order:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var hookErr *HookError
+	if err := <-errCh; !errors.As(err, &hookErr) || !errors.Is(err, cause) {
+		t.Fatalf("expected a HookError, got %v", err)
+	}
+	if hookErr.HookID != id || hookErr.Event != EventBatchDel || hookErr.BatchID == "" || hookErr.Key != "" {
+		t.Errorf("unexpected error %+v", hookErr)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Only the keys matching the filter are passed, and batches without any are skipped
+	if len(batches) != 1 || !slices.Equal(batches[0].Keys(), []string{"user:1", "user:2"}) {
+		t.Errorf("unexpected batches %+v", batches)
+	}
+}
+
+func TestClient_BatchHooksNamespace(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var outer, inner []string
+
+	_, _, unregisterOuter := c.RegisterBatchHook(func(_ context.Context, batch Batch) error {
+		outer = batch.Keys()
+		return nil
+	}, HookOptions{})
+	defer unregisterOuter()
+
+	billing := c.WithNamespace("billing")
+	_, _, unregisterInner := billing.RegisterBatchHook(func(_ context.Context, batch Batch) error {
+		inner = batch.Keys()
+		return nil
+	}, HookOptions{})
+	defer unregisterInner()
+
+	if err := billing.BatchSet(context.Background(), map[string]any{"a": "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(inner, []string{"a"}) || !slices.Equal(outer, []string{"billing:a"}) {
+		t.Errorf("got keys %v and %v, want [a] and [billing:a]", inner, outer)
+	}
+}
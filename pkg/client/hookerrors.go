@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type (
+	// HookError is an error of a hook for an event, reported on the error channel of the hook
+	// and to HookOptions.OnError.
+	HookError struct {
+		HookID string
+		Event  EventType
+
+		// Key is the key of the event, or empty for the errors of batch hooks.
+		Key string
+
+		// BatchID is the ID of the batch the event belongs to, if any. See BatchIDFromContext.
+		BatchID string
+
+		// Attempt is the number of times the hook was called for the event, see HookOptions.Retry.
+		// It is zero if the hook was not called, e.g. when the event was dropped by an async hook.
+		Attempt int
+
+		Err error
+	}
+
+	// RetryPolicy configures how a hook is retried when it returns an error.
+	//
+	// The hook is retried until it succeeds, MaxAttempts is reached or the context of the operation is done.
+	// Retries of sync hooks and pre-hooks delay the operation, and retries of async hooks occupy a worker.
+	//
+	// Example:
+	//
+	//	client.HookOptions{
+	//	    Async: true,
+	//	    Retry: client.RetryPolicy{MaxAttempts: 5, Backoff: 100 * time.Millisecond, MaxBackoff: 5 * time.Second},
+	//	}
+	RetryPolicy struct {
+		// MaxAttempts is the maximum number of calls of the hook per event, including the first one.
+		// If zero or one, the hook is not retried.
+		MaxAttempts int
+
+		// Backoff is the delay before the first retry, doubled after each retry.
+		// If zero, the hook is retried immediately.
+		Backoff time.Duration
+
+		// MaxBackoff caps the delay between retries. If zero, the delay is not capped.
+		MaxBackoff time.Duration
+	}
+
+	// HookStats are the counters of a hook, see HooksRegistry.Stats.
+	HookStats struct {
+		// Errors is the number of errors reported for the hook, including the dropped events.
+		Errors uint64
+
+		// Retries is the number of times the hook was retried.
+		Retries uint64
+
+		// DroppedErrors is the number of errors that were not delivered on the error channel because it was full.
+		DroppedErrors uint64
+
+		// DroppedEvents is the number of events an async hook dropped because its queue was full,
+		// or discarded because the hook was closed before processing them.
+		DroppedEvents uint64
+	}
+)
+
+func (e *HookError) Error() string {
+	subject := e.Key
+	if subject == "" && e.BatchID != "" {
+		subject = "batch " + e.BatchID
+	}
+
+	if e.Attempt == 0 {
+		return fmt.Sprintf("hook %s: %s %s: %v", e.HookID, e.Event, subject, e.Err)
+	}
+
+	return fmt.Sprintf("hook %s: %s %s (attempt %d): %v", e.HookID, e.Event, subject, e.Attempt, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// call calls the hook through fn, retrying it according to its retry policy, with the timeout of the hook
+// applied to each attempt. Retries stop once ctx is done, which for async hooks only happens when the hooks
+// are closed, not when the operation is cancelled. Returns the number of attempts and the error of the last one.
+func (r *hookRegistration) call(ctx context.Context, fn func(ctx context.Context) error) (int, error) {
+	policy := r.options.Retry
+	backoff := policy.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := r.attempt(ctx, fn)
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return attempt, err
+		}
+
+		if backoff > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return attempt, err
+			}
+
+			backoff *= 2
+			if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+				backoff = policy.MaxBackoff
+			}
+		}
+
+		r.retries.Add(1)
+	}
+}
+
+// attempt calls the hook through fn once, with its timeout.
+func (r *hookRegistration) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.options.Timeout)
+		defer cancel()
+	}
+
+	return fn(ctx)
+}
+
+// report delivers an error of the hook to its error handler, and on its error channel on a best-effort basis.
+func (r *hookRegistration) report(err *HookError) {
+	r.errors.Add(1)
+
+	if r.options.OnError != nil {
+		r.options.OnError(err)
+	}
+
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+
+	if r.errClosed {
+		return
+	}
+
+	select {
+	case r.errCh <- err:
+	default:
+		// Channel is full, drop the error
+		r.droppedErrors.Add(1)
+	}
+}
+
+// closeErrors closes the error channel of the hook.
+func (r *hookRegistration) closeErrors() {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+
+	if !r.errClosed {
+		r.errClosed = true
+		close(r.errCh)
+	}
+}
+
+// stats returns the counters of the hook.
+func (r *hookRegistration) stats() HookStats {
+	return HookStats{
+		Errors:        r.errors.Load(),
+		Retries:       r.retries.Load(),
+		DroppedErrors: r.droppedErrors.Load(),
+		DroppedEvents: r.droppedEvents.Load(),
+	}
+}
+
+// Stats returns the counters of the hook with the given ID. Returns false if no such hook is registered.
+func (hr *HooksRegistry) Stats(id string) (HookStats, bool) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	registration, ok := hr.hooks[id]
+	if !ok {
+		return HookStats{}, false
+	}
+
+	return registration.stats(), true
+}
@@ -0,0 +1,245 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func TestHookError(t *testing.T) {
+	cause := errors.New("hook error")
+
+	err := &HookError{HookID: "42", Event: EventSet, Key: "key", Attempt: 2, Err: cause}
+	if got, want := err.Error(), "hook 42: SET key (attempt 2): hook error"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected HookError to unwrap the hook error")
+	}
+
+	err = &HookError{HookID: "42", Event: EventSet, Key: "key", Err: errs.ErrHookQueueFull}
+	if got, want := err.Error(), "hook 42: SET key: hook queue is full"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHookRegistry_OnError(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	cause := errors.New("hook error")
+	var handled []*HookError
+
+	id, errCh, unregister := registry.RegisterHook(func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		return cause
+	}, HookOptions{OnError: func(err *HookError) { handled = append(handled, err) }})
+	defer unregister()
+
+	registry.Run(context.Background(), EventDelete, "key", nil)
+
+	if len(handled) != 1 {
+		t.Fatalf("expected the error handler to be called once, got %d", len(handled))
+	}
+	want := HookError{HookID: id, Event: EventDelete, Key: "key", Attempt: 1, Err: cause}
+	if *handled[0] != want {
+		t.Errorf("got %+v, want %+v", *handled[0], want)
+	}
+
+	// Errors are still delivered on the channel
+	if err := <-errCh; err != handled[0] {
+		t.Errorf("got %v, want %v", err, handled[0])
+	}
+}
+
+func TestHookRegistry_Stats(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	id, _, unregister := registry.RegisterHook(func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		return errors.New("hook error")
+	}, HookOptions{})
+	defer unregister()
+
+	// The error channel holds 100 errors
+	for range 150 {
+		registry.Run(context.Background(), EventSet, "key", nil)
+	}
+
+	stats, ok := registry.Stats(id)
+	if !ok {
+		t.Fatal("expected the hook to be found")
+	}
+	if want := (HookStats{Errors: 150, DroppedErrors: 50}); stats != want {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+
+	if _, ok := registry.Stats("unknown"); ok {
+		t.Error("expected unknown hook not to be found")
+	}
+}
+
+func TestHookRegistry_Retry(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	calls := 0
+	id, errCh, unregister := registry.RegisterHook(func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		calls++
+		if calls%4 != 0 {
+			return errors.New("transient error")
+		}
+		return nil
+	}, HookOptions{Retry: RetryPolicy{MaxAttempts: 4, Backoff: 5 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}})
+	defer unregister()
+
+	start := time.Now()
+	registry.Run(context.Background(), EventSet, "key", nil)
+
+	// Backoffs of 5, 10 and 10 milliseconds
+	if elapsed := time.Since(start); elapsed < 25*time.Millisecond {
+		t.Errorf("expected the retries to back off, took %s", elapsed)
+	}
+	if calls != 4 {
+		t.Errorf("got %d calls, want 4", calls)
+	}
+
+	select {
+	case err := <-errCh:
+		t.Errorf("expected the hook to succeed after retries, got %v", err)
+	default:
+	}
+
+	stats, _ := registry.Stats(id)
+	if stats.Retries != 3 || stats.Errors != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestHookRegistry_RetryExhausted(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	_, errCh, unregister := registry.RegisterHook(func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		return errors.New("permanent error")
+	}, HookOptions{Retry: RetryPolicy{MaxAttempts: 3}})
+	defer unregister()
+
+	registry.Run(context.Background(), EventSet, "key", nil)
+
+	var hookErr *HookError
+	if err := <-errCh; !errors.As(err, &hookErr) || hookErr.Attempt != 3 {
+		t.Errorf("expected the error of the third attempt, got %v", err)
+	}
+
+	// Retries stop once the context of the operation is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, errCh, unregisterSlow := registry.RegisterHook(func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		return errors.New("permanent error")
+	}, HookOptions{Events: []EventType{EventDelete}, Retry: RetryPolicy{MaxAttempts: 100, Backoff: 10 * time.Millisecond}})
+	defer unregisterSlow()
+
+	registry.Run(ctx, EventDelete, "key", nil)
+
+	if err := <-errCh; !errors.As(err, &hookErr) || hookErr.Attempt >= 100 {
+		t.Errorf("expected the retries to stop with the context, got %v", err)
+	}
+}
+
+func TestHookRegistry_RetryAsync(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	release := make(chan struct{})
+	var calls atomic.Int64
+
+	_, errCh, unregister := registry.RegisterHook(func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		<-release
+		calls.Add(1)
+		return errors.New("permanent error")
+	}, HookOptions{Async: true, Retry: RetryPolicy{MaxAttempts: 3, Backoff: 5 * time.Millisecond}})
+	defer unregister()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	registry.Run(ctx, EventSet, "key", nil)
+
+	// Async hooks keep retrying once the operation is cancelled
+	cancel()
+	close(release)
+
+	select {
+	case err := <-errCh:
+		var hookErr *HookError
+		if !errors.As(err, &hookErr) || hookErr.Attempt != 3 {
+			t.Errorf("expected the error of the third attempt, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the async hook to report its error")
+	}
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("got %d calls, want 3", got)
+	}
+}
+
+func TestHookPool_DroppedEvents(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	release := make(chan struct{})
+	hook, started, _ := blockingHook(release)
+
+	var dropped *HookError
+	id, _, _ := registry.RegisterHook(hook, HookOptions{
+		Async:     true,
+		Workers:   1,
+		QueueSize: 1,
+		OnError:   func(err *HookError) { dropped = err },
+	})
+
+	registry.Run(context.Background(), EventSet, "a", nil)
+	<-started
+	registry.Run(context.Background(), EventSet, "b", nil)
+	registry.Run(context.Background(), EventSet, "c", nil)
+
+	stats, _ := registry.Stats(id)
+	if stats.DroppedEvents != 1 || stats.Errors != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if dropped == nil || dropped.Key != "c" || dropped.Attempt != 0 || !errors.Is(dropped, errs.ErrHookQueueFull) {
+		t.Errorf("unexpected error %v", dropped)
+	}
+
+	close(release)
+	if err := registry.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_PreHookRejectHookError(t *testing.T) {
+	c, err := New(&mock.MockKV{Data: map[string][]byte{}}, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _, unregister := c.RegisterPreHook(func(_ context.Context, _ EventType, _ string, _ []byte) (string, []byte, error) {
+		return "", nil, errors.New("rejected")
+	}, HookOptions{FailOperation: true})
+	defer unregister()
+
+	err = c.Set(context.Background(), "key", "value")
+
+	var hookErr *HookError
+	if !errors.Is(err, errs.ErrHookRejected) || !errors.As(err, &hookErr) {
+		t.Fatalf("expected ErrHookRejected wrapping a HookError, got %v", err)
+	}
+	if hookErr.HookID != id || hookErr.Event != EventBeforeSet || hookErr.Key != "key" {
+		t.Errorf("unexpected error %+v", hookErr)
+	}
+
+	if stats, _ := c.HookStats(id); stats.Errors != 0 {
+		t.Errorf("expected rejections not to be reported, got %+v", stats)
+	}
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+const (
+	// DefaultHookWorkers is the number of workers of an async hook if HookOptions.Workers is zero.
+	DefaultHookWorkers = 4
+	// DefaultHookQueueSize is the number of pending events of an async hook if HookOptions.QueueSize is zero.
+	DefaultHookQueueSize = 1024
+)
+
+// OverflowPolicy determines what happens to the events of an async hook when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowDrop drops the new event and reports errs.ErrHookQueueFull on the error channel of the hook.
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock blocks the operation until the event can be queued, or its context is done.
+	OverflowBlock
+	// OverflowCoalesce replaces the pending event of the same key with the new one, so that the hook only
+	// receives the latest event of a key. If no event of the key is pending, the new event is dropped.
+	OverflowCoalesce
+)
+
+type (
+	// hookTask is an event queued for an async hook.
+	hookTask struct {
+		// ctx is the context of the operation without its cancellation, since the hook runs after the operation
+		// returned. The hook is only cancelled once the pool is cancelled.
+		ctx   context.Context //nolint:containedctx // the context of the operation is passed to the hook
+		evt   EventType
+		key   string
+		value []byte
+
+		// batch is the batch of the event for batch hooks, whose tasks are keyed by batch ID.
+		batch *Batch
+	}
+
+	// hookPool executes the events of an async hook with a bounded queue and a fixed number of workers.
+	hookPool struct {
+		registration *hookRegistration
+		queue        chan *hookTask
+
+		// mu guards the queue, so that no event is queued once it is closed. closing is closed first,
+		// so that the operations blocked on a full queue release mu before the queue is closed.
+		mu        sync.RWMutex
+		closing   chan struct{}
+		closeOnce sync.Once
+
+		// pending are the queued events by key, to be coalesced. OverflowCoalesce only.
+		pendingMu sync.Mutex
+		pending   map[string]*hookTask
+
+		// ctx is cancelled to abort the pending events and the running hooks.
+		ctx    context.Context //nolint:containedctx // cancels the work of the pool
+		cancel context.CancelFunc
+		done   chan struct{} // closed once the workers have exited
+	}
+)
+
+// newHookPool starts the workers of the async hook.
+func newHookPool(hr *HooksRegistry, registration *hookRegistration) *hookPool {
+	opts := registration.options
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultHookWorkers
+	}
+
+	size := opts.QueueSize
+	if size <= 0 {
+		size = DefaultHookQueueSize
+	}
+
+	p := &hookPool{
+		registration: registration,
+		queue:        make(chan *hookTask, size),
+		closing:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+
+	if opts.Overflow == OverflowCoalesce {
+		p.pending = make(map[string]*hookTask, size)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for range workers {
+		go func() {
+			defer wg.Done()
+			p.work(hr)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(p.done)
+	}()
+
+	return p
+}
+
+// enqueue queues the event according to the overflow policy of the hook.
+// ctx is the context of the operation, which stops waiting for a full queue once done.
+func (p *hookPool) enqueue(ctx context.Context, t *hookTask) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	select {
+	case <-p.closing:
+		p.discard()
+		return
+	default:
+	}
+
+	switch p.registration.options.Overflow {
+	case OverflowBlock:
+		select {
+		case p.queue <- t:
+		case <-ctx.Done():
+			p.drop(t, fmt.Errorf("%w: %w", errs.ErrHookQueueFull, ctx.Err()))
+		case <-p.closing:
+			p.discard()
+		case <-p.ctx.Done():
+			p.discard()
+		}
+		return
+
+	case OverflowCoalesce:
+		p.pendingMu.Lock()
+		defer p.pendingMu.Unlock()
+
+		select {
+		case p.queue <- t:
+			p.pending[t.key] = t
+			return
+		default:
+		}
+
+		if pending, ok := p.pending[t.key]; ok {
+			pending.ctx, pending.evt, pending.value = t.ctx, t.evt, t.value
+			return
+		}
+
+	case OverflowDrop:
+		select {
+		case p.queue <- t:
+			return
+		default:
+		}
+	}
+
+	p.drop(t, errs.ErrHookQueueFull)
+}
+
+// drop reports an event dropped because of err.
+func (p *hookPool) drop(t *hookTask, err error) {
+	p.registration.droppedEvents.Add(1)
+
+	if t.batch != nil {
+		p.registration.report(&HookError{HookID: p.registration.id, Event: t.evt, BatchID: t.batch.ID, Err: err})
+		return
+	}
+
+	batchID, _ := BatchIDFromContext(t.ctx)
+	p.registration.report(&HookError{HookID: p.registration.id, Event: t.evt, Key: t.key, BatchID: batchID, Err: err})
+}
+
+// discard counts an event discarded because the pool is closed or cancelled.
+func (p *hookPool) discard() {
+	p.registration.droppedEvents.Add(1)
+}
+
+// take returns the event of the task, which can no longer be coalesced.
+func (p *hookPool) take(t *hookTask) hookTask {
+	if p.pending == nil {
+		return *t
+	}
+
+	p.pendingMu.Lock()
+	defer p.pendingMu.Unlock()
+
+	if p.pending[t.key] == t {
+		delete(p.pending, t.key)
+	}
+
+	return *t
+}
+
+// work executes the queued events until the queue is closed. Once the pool is cancelled,
+// the remaining events are discarded.
+func (p *hookPool) work(hr *HooksRegistry) {
+	for t := range p.queue {
+		if p.ctx.Err() != nil {
+			p.discard()
+			continue
+		}
+
+		task := p.take(t)
+
+		ctx, cancel := context.WithCancel(task.ctx)
+		stop := context.AfterFunc(p.ctx, cancel)
+		if task.batch != nil {
+			hr.executeBatchHook(ctx, p.registration, *task.batch)
+		} else {
+			hr.executeHook(ctx, p.registration, task.evt, task.key, task.value)
+		}
+		stop()
+		cancel()
+	}
+}
+
+// close stops queuing events, discarding those of the operations blocked on a full queue.
+// The workers exit once the pending events are processed.
+func (p *hookPool) close() {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		close(p.queue)
+	})
+}
+
+// wait waits for the pending events to be processed until ctx is done.
+func (p *hookPool) wait(ctx context.Context) error {
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
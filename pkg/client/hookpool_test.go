@@ -0,0 +1,364 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+// blockingHook returns a hook recording the values it receives, which blocks until release is closed.
+// started receives the value of each event once the hook is running.
+func blockingHook(release <-chan struct{}) (HookFunc, <-chan string, func() []string) {
+	var (
+		mu     sync.Mutex
+		values []string
+	)
+
+	started := make(chan string, 100)
+
+	hook := func(ctx context.Context, _ EventType, _ string, value []byte) error {
+		started <- string(value)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		values = append(values, string(value))
+		return nil
+	}
+
+	return hook, started, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(values)
+	}
+}
+
+func TestHookPool_Workers(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	var running, maxRunning atomic.Int32
+
+	hook := func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}
+
+	_, _, unregister := registry.RegisterHook(hook, HookOptions{Async: true, Workers: 2})
+	defer unregister()
+
+	for range 20 {
+		registry.Run(context.Background(), EventSet, "key", nil)
+	}
+
+	if err := registry.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := maxRunning.Load(); got != 2 {
+		t.Errorf("got %d concurrent events, want 2", got)
+	}
+}
+
+func TestHookPool_OverflowDrop(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	release := make(chan struct{})
+	hook, started, values := blockingHook(release)
+
+	_, errCh, _ := registry.RegisterHook(hook, HookOptions{Async: true, Workers: 1, QueueSize: 1})
+
+	registry.Run(context.Background(), EventSet, "key", []byte("1"))
+	<-started
+	registry.Run(context.Background(), EventSet, "key", []byte("2"))
+	registry.Run(context.Background(), EventSet, "key", []byte("3"))
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, errs.ErrHookQueueFull) {
+			t.Errorf("expected ErrHookQueueFull, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the dropped event to be reported")
+	}
+
+	close(release)
+	if err := registry.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := values(), []string{"1", "2"}; !slices.Equal(got, want) {
+		t.Errorf("got values %v, want %v", got, want)
+	}
+}
+
+func TestHookPool_OverflowCoalesce(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	release := make(chan struct{})
+	hook, started, values := blockingHook(release)
+
+	_, errCh, _ := registry.RegisterHook(hook, HookOptions{Async: true, Workers: 1, QueueSize: 1, Overflow: OverflowCoalesce})
+
+	registry.Run(context.Background(), EventSet, "a", []byte("a1"))
+	<-started
+	registry.Run(context.Background(), EventSet, "a", []byte("a2"))
+	registry.Run(context.Background(), EventSet, "a", []byte("a3"))
+	registry.Run(context.Background(), EventSet, "b", []byte("b1"))
+
+	close(release)
+	if err := registry.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := values(), []string{"a1", "a3"}; !slices.Equal(got, want) {
+		t.Errorf("got values %v, want %v", got, want)
+	}
+
+	// Events of other keys are dropped
+	if err := <-errCh; !errors.Is(err, errs.ErrHookQueueFull) {
+		t.Errorf("expected ErrHookQueueFull, got %v", err)
+	}
+}
+
+func TestHookPool_OverflowBlock(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	release := make(chan struct{})
+	hook, started, values := blockingHook(release)
+
+	_, errCh, _ := registry.RegisterHook(hook, HookOptions{Async: true, Workers: 1, QueueSize: 1, Overflow: OverflowBlock})
+
+	registry.Run(context.Background(), EventSet, "key", []byte("1"))
+	<-started
+	registry.Run(context.Background(), EventSet, "key", []byte("2"))
+
+	// The queue is full: Run blocks until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	registry.Run(ctx, EventSet, "key", []byte("3"))
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected Run to block, returned after %s", elapsed)
+	}
+	if err := <-errCh; !errors.Is(err, errs.ErrHookQueueFull) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected ErrHookQueueFull wrapping DeadlineExceeded, got %v", err)
+	}
+
+	// Run resumes once an event is processed
+	done := make(chan struct{})
+	go func() {
+		registry.Run(context.Background(), EventSet, "key", []byte("4"))
+		close(done)
+	}()
+
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to resume")
+	}
+
+	if err := registry.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := values(), []string{"1", "2", "4"}; !slices.Equal(got, want) {
+		t.Errorf("got values %v, want %v", got, want)
+	}
+}
+
+func TestHookPool_Timeout(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	hook, _, _ := blockingHook(make(chan struct{}))
+
+	_, errCh, unregister := registry.RegisterHook(hook, HookOptions{Async: true, Timeout: 20 * time.Millisecond})
+	defer unregister()
+
+	registry.Run(context.Background(), EventSet, "key", nil)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the async hook to time out")
+	}
+}
+
+func TestHookPool_CallerCancelled(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	type ctxKey struct{}
+
+	release := make(chan struct{})
+	received := make(chan error, 1)
+
+	_, _, unregister := registry.RegisterHook(func(ctx context.Context, _ EventType, _ string, _ []byte) error {
+		<-release
+		if ctx.Value(ctxKey{}) != "value" {
+			t.Error("expected the hook context to carry the values of the caller")
+		}
+		received <- ctx.Err()
+		return nil
+	}, HookOptions{Async: true})
+	defer unregister()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+	registry.Run(ctx, EventSet, "key", nil)
+
+	// The operation returns before the hook runs
+	cancel()
+	close(release)
+
+	select {
+	case err := <-received:
+		if err != nil {
+			t.Errorf("expected the hook context not to be cancelled with the caller, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the async hook to run")
+	}
+}
+
+func TestHookPool_CloseCancels(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	hook, started, values := blockingHook(make(chan struct{}))
+
+	_, errCh, _ := registry.RegisterHook(hook, HookOptions{Async: true, Workers: 1})
+
+	registry.Run(context.Background(), EventSet, "key", []byte("1"))
+	registry.Run(context.Background(), EventSet, "key", []byte("2"))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := registry.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+
+	// The running hook is cancelled and the pending event discarded
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the running hook to be cancelled, got %v", err)
+	}
+	if got := values(); len(got) != 0 {
+		t.Errorf("expected no event to complete, got %v", got)
+	}
+
+	// Events are no longer queued
+	registry.Run(context.Background(), EventSet, "key", []byte("3"))
+	select {
+	case v := <-started:
+		t.Errorf("expected no event to be processed after Close, got %q", v)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestHookPool_CloseBlockedOperation(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	hook, started, _ := blockingHook(make(chan struct{}))
+
+	id, _, _ := registry.RegisterHook(hook, HookOptions{Async: true, Workers: 1, QueueSize: 1, Overflow: OverflowBlock})
+
+	registry.Run(context.Background(), EventSet, "key", []byte("1"))
+	<-started
+	registry.Run(context.Background(), EventSet, "key", []byte("2"))
+
+	// The queue is full and the operation has no deadline
+	blocked := make(chan struct{})
+	go func() {
+		registry.Run(context.Background(), EventSet, "key", []byte("3"))
+		close(blocked)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	closed := make(chan error, 1)
+	go func() { closed <- registry.Close(ctx) }()
+
+	select {
+	case err := <-closed:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Close to return once ctx is done")
+	}
+
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("expected the blocked operation to resume")
+	}
+
+	// The event of the blocked operation and the pending event are both counted as dropped
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats, _ := registry.Stats(id)
+		if stats.DroppedEvents == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d dropped events, want 2", stats.DroppedEvents)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClient_CloseDrainsHooks(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New(), HookDrainTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var processed atomic.Int32
+
+	_, _, _ = c.RegisterHook(func(_ context.Context, _ EventType, _ string, _ []byte) error {
+		time.Sleep(time.Millisecond)
+		processed.Add(1)
+		return nil
+	}, HookOptions{Async: true, Workers: 1})
+
+	ctx := context.Background()
+	for range 10 {
+		if err := c.Set(ctx, "key", "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := processed.Load(); got != 10 {
+		t.Errorf("got %d processed events, want 10", got)
+	}
+}
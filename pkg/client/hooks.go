@@ -1,13 +1,19 @@
 package client
 
 import (
+	"cmp"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/kivigo/kivigo/pkg/errs"
 )
 
 // EventType represents the type of operation that triggered a hook.
@@ -18,12 +24,30 @@ const (
 	EventSet EventType = "SET"
 	// EventSetRaw is triggered when SetRaw operation is successful.
 	EventSetRaw EventType = "SET_RAW"
+	// EventDeleteRaw is triggered when DeleteRaw operation is successful.
+	EventDeleteRaw EventType = "DELETE_RAW"
 	// EventDelete is triggered when Delete operation is successful.
 	EventDelete EventType = "DELETE"
 	// EventBatchSet is triggered when BatchSet operation is successful.
 	EventBatchSet EventType = "BATCH_SET"
 	// EventBatchDel is triggered when BatchDelete operation is successful.
 	EventBatchDel EventType = "BATCH_DELETE"
+
+	// EventGet is triggered when Get, GetRaw, BatchGet or HasKey finds a key, with the value as stored.
+	// BatchGet triggers it for each key found.
+	EventGet EventType = "GET"
+	// EventMiss is triggered when Get, GetRaw, BatchGet or HasKey does not find a key, or finds it expired.
+	// BatchGet triggers it for each key missing.
+	EventMiss EventType = "MISS"
+	// EventList is triggered when List operation is successful. The key is the listed prefix and the value is nil.
+	EventList EventType = "LIST"
+
+	// EventBeforeSet is triggered before a value is written, including by batches and transactions.
+	// Only pre-hooks receive it, see RegisterPreHook.
+	EventBeforeSet EventType = "BEFORE_SET"
+	// EventBeforeDelete is triggered before a key is deleted, including by batches and transactions.
+	// Only pre-hooks receive it, see RegisterPreHook.
+	EventBeforeDelete EventType = "BEFORE_DELETE"
 )
 
 // HookFunc is the function signature for hooks.
@@ -31,6 +55,12 @@ const (
 // For delete operations, value will be nil.
 type HookFunc func(ctx context.Context, evt EventType, key string, value []byte) error
 
+// PreHookFunc is the function signature for pre-hooks, called before the operation reaches the backend.
+// It receives the key as stored and the encoded value, nil for delete operations, and returns the key
+// and value the operation continues with, which can be modified.
+// Returning an error rejects the operation if the hook is registered with HookOptions.FailOperation.
+type PreHookFunc func(ctx context.Context, evt EventType, key string, value []byte) (string, []byte, error)
+
 // HookFilterFunc is a function that returns true if the hook should be executed for the given key.
 type HookFilterFunc func(key string) bool
 
@@ -45,27 +75,96 @@ type HookOptions struct {
 	Filter HookFilterFunc
 
 	// Async specifies whether the hook should be executed asynchronously.
+	// Async hooks process their events from a bounded queue with a fixed number of workers, see Workers,
+	// QueueSize and Overflow. They receive the values of the context of the operation but not its cancellation,
+	// as they usually run after the operation returned; they are cancelled once the hooks are closed, see Close.
 	// If false, the hook is executed synchronously.
 	Async bool
 
-	// Timeout specifies the maximum time to wait for a synchronous hook to complete.
+	// Timeout specifies the maximum time to wait for the hook to complete for an event.
 	// If zero, no timeout is applied.
-	// This is ignored for async hooks.
 	Timeout time.Duration
+
+	// Workers is the number of events an async hook processes concurrently.
+	// If zero, DefaultHookWorkers is used. This is ignored for sync hooks.
+	Workers int
+
+	// QueueSize is the number of events an async hook can hold pending before Overflow applies.
+	// If zero, DefaultHookQueueSize is used. This is ignored for sync hooks.
+	QueueSize int
+
+	// Overflow determines what happens to the events of an async hook when its queue is full.
+	// This is ignored for sync hooks.
+	Overflow OverflowPolicy
+
+	// Priority orders the execution of hooks: hooks with a higher priority run first,
+	// and hooks with the same priority run in registration order.
+	Priority int
+
+	// FailOperation makes the errors of a pre-hook reject the operation, which then returns an error
+	// wrapping errs.ErrHookRejected and the *HookError. Otherwise, the errors are reported as for other hooks
+	// and the operation continues with the key and value the hook received.
+	// This is ignored for hooks registered with RegisterHook.
+	FailOperation bool
+
+	// OnError is called with each error of the hook, in addition to its delivery on the error channel.
+	// It is called from the goroutine running the hook, so it delays the operation for sync hooks.
+	OnError func(err *HookError)
+
+	// Retry configures how the hook is retried when it returns an error.
+	// If zero, the hook is not retried.
+	Retry RetryPolicy
 }
 
 // hookRegistration represents a registered hook with its metadata.
 type hookRegistration struct {
 	id       string
+	seq      uint64 // registration order
 	callback HookFunc
+	pre      PreHookFunc
+	batch    BatchHookFunc
 	options  HookOptions
-	errCh    chan error
+	pool     *hookPool // async hooks only
+
+	// errMu guards errCh, which is closed once the hook is unregistered and its pending events are processed.
+	errMu     sync.Mutex
+	errCh     chan error
+	errClosed bool
+
+	// Counters, see HookStats
+	errors        atomic.Uint64
+	retries       atomic.Uint64
+	droppedErrors atomic.Uint64
+	droppedEvents atomic.Uint64
+}
+
+// hookKind is the kind of a hook, determining the events it is run for.
+type hookKind int
+
+const (
+	hookKindPost  hookKind = iota // run per key after operations, see RegisterHook
+	hookKindPre                   // run per key before write operations, see RegisterPreHook
+	hookKindBatch                 // run per batch after batch operations, see RegisterBatchHook
+)
+
+// kind returns the kind of the hook.
+func (r *hookRegistration) kind() hookKind {
+	switch {
+	case r.pre != nil:
+		return hookKindPre
+	case r.batch != nil:
+		return hookKindBatch
+	default:
+		return hookKindPost
+	}
 }
 
 // HooksRegistry manages hook registration and execution.
 type HooksRegistry struct {
-	mu    sync.RWMutex
-	hooks map[string]*hookRegistration
+	mu     sync.RWMutex
+	hooks  map[string]*hookRegistration
+	seq    uint64
+	closed bool
 }
 
 // NewHooksRegistry creates a new hooks registry.
@@ -76,23 +175,44 @@ func NewHooksRegistry() *HooksRegistry {
 }
 
 // RegisterHook registers a new hook with the given callback and options.
-// Returns a unique hook ID, an error channel for receiving hook errors,
+// Returns a unique hook ID, an error channel for receiving hook errors as *HookError,
 // and an unregister function to remove the hook.
 func (hr *HooksRegistry) RegisterHook(cb HookFunc, opts HookOptions) (string, <-chan error, func()) {
-	id := generateHookID()
+	return hr.register(&hookRegistration{callback: cb, options: opts})
+}
+
+// RegisterPreHook registers a new pre-hook with the given callback and options.
+// Pre-hooks run synchronously before write operations, with the events EventBeforeSet and EventBeforeDelete,
+// and can modify or reject them. The Async option is ignored.
+// Returns a unique hook ID, an error channel for receiving hook errors as *HookError,
+// and an unregister function to remove the hook.
+func (hr *HooksRegistry) RegisterPreHook(cb PreHookFunc, opts HookOptions) (string, <-chan error, func()) {
+	return hr.register(&hookRegistration{pre: cb, options: opts})
+}
+
+// register adds the registration with a new ID.
+func (hr *HooksRegistry) register(registration *hookRegistration) (string, <-chan error, func()) {
+	id := generateID()
 	errCh := make(chan error, 100) // Buffered channel for best-effort error delivery
 
-	registration := &hookRegistration{
-		id:       id,
-		callback: cb,
-		options:  opts,
-		errCh:    errCh,
+	registration.id = id
+	registration.errCh = errCh
+
+	if registration.options.Async && registration.kind() != hookKindPre {
+		registration.pool = newHookPool(hr, registration)
 	}
 
 	hr.mu.Lock()
+	hr.seq++
+	registration.seq = hr.seq
 	hr.hooks[id] = registration
+	closed := hr.closed
 	hr.mu.Unlock()
 
+	if closed && registration.pool != nil {
+		registration.pool.close()
+	}
+
 	unregister := func() {
 		hr.UnregisterHook(id)
 	}
@@ -101,53 +221,138 @@ func (hr *HooksRegistry) RegisterHook(cb HookFunc, opts HookOptions) (string, <-
 }
 
 // UnregisterHook removes a hook by its ID.
+// The pending events of an async hook are still processed, then its error channel is closed.
 func (hr *HooksRegistry) UnregisterHook(id string) {
 	hr.mu.Lock()
-	defer hr.mu.Unlock()
+	registration, exists := hr.hooks[id]
+	delete(hr.hooks, id)
+	hr.mu.Unlock()
+
+	if !exists {
+		return
+	}
 
-	if registration, exists := hr.hooks[id]; exists {
-		close(registration.errCh)
-		delete(hr.hooks, id)
+	if registration.pool == nil {
+		registration.closeErrors()
+		return
 	}
+
+	registration.pool.close()
+	go func() {
+		<-registration.pool.done
+		registration.closeErrors()
+	}()
+}
+
+// Close stops the async hooks: their new events are dropped, including those of the operations blocked
+// on a full queue, and Close waits for their pending events to be processed until ctx is done. The remaining
+// events are then discarded, the running hooks are cancelled and the error of ctx is returned.
+// Sync hooks and pre-hooks are not affected.
+func (hr *HooksRegistry) Close(ctx context.Context) error {
+	hr.mu.Lock()
+	hr.closed = true
+	pools := make([]*hookPool, 0, len(hr.hooks))
+	for _, registration := range hr.hooks {
+		if registration.pool != nil {
+			pools = append(pools, registration.pool)
+		}
+	}
+	hr.mu.Unlock()
+
+	// Once ctx is done, the remaining events are discarded and the running hooks are cancelled,
+	// whichever step of the shutdown is in progress
+	for _, p := range pools {
+		stop := context.AfterFunc(ctx, p.cancel)
+		defer stop()
+	}
+
+	for _, p := range pools {
+		p.close()
+	}
+
+	for _, p := range pools {
+		if err := p.wait(ctx); err != nil {
+			return fmt.Errorf("async hooks did not complete: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // Run executes all registered hooks that match the given event and key.
 // This method is called internally after successful operations.
+// Hooks are started by decreasing priority, then in registration order.
 func (hr *HooksRegistry) Run(ctx context.Context, evt EventType, key string, value []byte) {
-	// Take a snapshot of hooks to avoid deadlocks
+	// Execute hooks from snapshot
+	for _, registration := range hr.snapshot(hookKindPost) {
+		if !hr.shouldExecuteHook(registration, evt, key) {
+			continue
+		}
+
+		if registration.pool != nil {
+			registration.pool.enqueue(ctx, &hookTask{ctx: context.WithoutCancel(ctx), evt: evt, key: key, value: value})
+		} else {
+			hr.executeHook(ctx, registration, evt, key, value)
+		}
+	}
+}
+
+// RunPre executes all registered pre-hooks that match the given event and key, by decreasing priority,
+// then in registration order. Each pre-hook receives the key and value returned by the previous one,
+// and is filtered against that key.
+// Returns the key and value to continue the operation with, or an error wrapping errs.ErrHookRejected
+// if a hook registered with HookOptions.FailOperation failed.
+func (hr *HooksRegistry) RunPre(ctx context.Context, evt EventType, key string, value []byte) (string, []byte, error) {
+	for _, registration := range hr.snapshot(hookKindPre) {
+		if !hr.shouldExecuteHook(registration, evt, key) {
+			continue
+		}
+
+		newKey, newValue, hookErr := hr.executePreHook(ctx, registration, evt, key, value)
+		if hookErr != nil {
+			if registration.options.FailOperation {
+				return "", nil, fmt.Errorf("%w: %w", errs.ErrHookRejected, hookErr)
+			}
+
+			registration.report(hookErr)
+			continue
+		}
+
+		if newKey == "" {
+			return "", nil, fmt.Errorf("%w: hook %s returned an empty key", errs.ErrHookRejected, registration.id)
+		}
+		key, value = newKey, newValue
+	}
+
+	return key, value, nil
+}
+
+// snapshot returns the hooks of the given kind, in execution order. Hooks are run from a snapshot to avoid deadlocks.
+func (hr *HooksRegistry) snapshot(kind hookKind) []*hookRegistration {
 	hr.mu.RLock()
 	snapshot := make([]*hookRegistration, 0, len(hr.hooks))
 	for _, registration := range hr.hooks {
-		if hr.shouldExecuteHook(registration, evt, key) {
+		if registration.kind() == kind {
 			snapshot = append(snapshot, registration)
 		}
 	}
 	hr.mu.RUnlock()
 
-	// Execute hooks from snapshot
-	for _, registration := range snapshot {
-		if registration.options.Async {
-			go hr.executeHookAsync(ctx, registration, evt, key, value)
-		} else {
-			hr.executeHookSync(ctx, registration, evt, key, value)
+	slices.SortFunc(snapshot, func(a, b *hookRegistration) int {
+		if c := cmp.Compare(b.options.Priority, a.options.Priority); c != 0 {
+			return c
 		}
-	}
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	return snapshot
 }
 
 // shouldExecuteHook determines if a hook should be executed based on event type and key.
 func (hr *HooksRegistry) shouldExecuteHook(registration *hookRegistration, evt EventType, key string) bool {
 	// Check event filter
-	if len(registration.options.Events) > 0 {
-		found := false
-		for _, allowedEvent := range registration.options.Events {
-			if allowedEvent == evt {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return false
-		}
+	if !registration.handles(evt) {
+		return false
 	}
 
 	// Check key filter
@@ -158,44 +363,47 @@ func (hr *HooksRegistry) shouldExecuteHook(registration *hookRegistration, evt E
 	return true
 }
 
-// executeHookSync executes a hook synchronously with optional timeout.
-func (hr *HooksRegistry) executeHookSync(ctx context.Context, registration *hookRegistration, evt EventType, key string, value []byte) {
-	var execCtx context.Context
-	var cancel context.CancelFunc
-
-	if registration.options.Timeout > 0 {
-		execCtx, cancel = context.WithTimeout(ctx, registration.options.Timeout)
-		defer cancel()
-	} else {
-		execCtx = ctx
-	}
+// handles reports whether the hook responds to the event.
+func (r *hookRegistration) handles(evt EventType) bool {
+	return len(r.options.Events) == 0 || slices.Contains(r.options.Events, evt)
+}
 
-	err := registration.callback(execCtx, evt, key, value)
+// executeHook executes a hook with its timeout and retry policy, and reports its error if any.
+func (hr *HooksRegistry) executeHook(ctx context.Context, registration *hookRegistration, evt EventType, key string, value []byte) {
+	attempt, err := registration.call(ctx, func(ctx context.Context) error {
+		return registration.callback(ctx, evt, key, value)
+	})
 	if err != nil {
-		// Best-effort error delivery
-		select {
-		case registration.errCh <- err:
-		default:
-			// Channel is full, drop the error
-		}
+		batchID, _ := BatchIDFromContext(ctx)
+		registration.report(&HookError{
+			HookID: registration.id, Event: evt, Key: key, BatchID: batchID, Attempt: attempt, Err: err,
+		})
 	}
 }
 
-// executeHookAsync executes a hook asynchronously.
-func (hr *HooksRegistry) executeHookAsync(ctx context.Context, registration *hookRegistration, evt EventType, key string, value []byte) {
-	err := registration.callback(ctx, evt, key, value)
+// executePreHook executes a pre-hook with its timeout and retry policy.
+func (hr *HooksRegistry) executePreHook(
+	ctx context.Context, registration *hookRegistration, evt EventType, key string, value []byte,
+) (string, []byte, *HookError) {
+	var (
+		newKey   string
+		newValue []byte
+	)
+
+	attempt, err := registration.call(ctx, func(ctx context.Context) error {
+		var err error
+		newKey, newValue, err = registration.pre(ctx, evt, key, value)
+		return err
+	})
 	if err != nil {
-		// Best-effort error delivery
-		select {
-		case registration.errCh <- err:
-		default:
-			// Channel is full, drop the error
-		}
+		return "", nil, &HookError{HookID: registration.id, Event: evt, Key: key, Attempt: attempt, Err: err}
 	}
+
+	return newKey, newValue, nil
 }
 
-// generateHookID generates a unique ID for a hook.
-func generateHookID() string {
+// generateID generates a unique ID for a hook or a batch.
+func generateID() string {
 	bytes := make([]byte, 8)
 	_, _ = rand.Read(bytes) // Best-effort random ID generation
 	return hex.EncodeToString(bytes)
@@ -1,14 +1,18 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/kivigo/encoders/json"
 
+	"github.com/kivigo/kivigo/pkg/errs"
 	"github.com/kivigo/kivigo/pkg/mock"
 )
 
@@ -408,3 +412,372 @@ func TestClient_HookErrorsDoNotFailOperations(t *testing.T) {
 		}
 	}
 }
+
+func TestHookRegistry_Priority(t *testing.T) {
+	registry := NewHooksRegistry()
+
+	var order []string
+
+	hookFunc := func(name string) HookFunc {
+		return func(ctx context.Context, evt EventType, key string, value []byte) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	for _, h := range []struct {
+		name     string
+		priority int
+	}{
+		{"low", -1}, {"first", 0}, {"high", 10}, {"second", 0}, {"third", 0},
+	} {
+		_, _, unregister := registry.RegisterHook(hookFunc(h.name), HookOptions{Priority: h.priority})
+		defer unregister()
+	}
+
+	want := []string{"high", "first", "second", "third", "low"}
+
+	for range 10 {
+		order = nil
+		registry.Run(context.Background(), EventSet, "test-key", nil)
+		if !slices.Equal(order, want) {
+			t.Fatalf("got order %v, want %v", order, want)
+		}
+	}
+}
+
+func TestClient_PreHookMutate(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+
+	// The lower priority hook sees the key rewritten by the higher priority one
+	_, _, unregisterUpper := c.RegisterPreHook(func(_ context.Context, evt EventType, key string, value []byte) (string, []byte, error) {
+		events = append(events, string(evt)+" "+key)
+		return key, bytes.ToUpper(value), nil
+	}, HookOptions{Events: []EventType{EventBeforeSet, EventBeforeDelete}})
+	defer unregisterUpper()
+
+	_, _, unregisterRename := c.RegisterPreHook(func(_ context.Context, _ EventType, key string, value []byte) (string, []byte, error) {
+		return "v2:" + key, value, nil
+	}, HookOptions{Priority: 1})
+	defer unregisterRename()
+
+	var post []string
+	_, _, unregisterPost := c.RegisterHook(func(_ context.Context, evt EventType, key string, _ []byte) error {
+		post = append(post, string(evt)+" "+key)
+		return nil
+	}, HookOptions{})
+	defer unregisterPost()
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "a", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(mockKV.Data["v2:a"]); got != `"VALUE"` {
+		t.Errorf("got %q, want %q", got, `"VALUE"`)
+	}
+	if _, ok := mockKV.Data["a"]; ok {
+		t.Error("expected the original key not to be written")
+	}
+
+	if err := c.BatchSet(ctx, map[string]any{"c": "x", "b": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mockKV.Data["v2:a"]; ok {
+		t.Error("expected the rewritten key to be deleted")
+	}
+
+	wantEvents := []string{"BEFORE_SET v2:a", "BEFORE_SET v2:b", "BEFORE_SET v2:c", "BEFORE_DELETE v2:a"}
+	if !slices.Equal(events, wantEvents) {
+		t.Errorf("got pre-hook events %v, want %v", events, wantEvents)
+	}
+
+	wantPost := []string{"SET v2:a", "BATCH_SET v2:b", "BATCH_SET v2:c", "DELETE v2:a"}
+	if !slices.Equal(post, wantPost) {
+		t.Errorf("got hook events %v, want %v", post, wantPost)
+	}
+}
+
+func TestClient_PreHookRenameConflicts(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, unregister := c.RegisterPreHook(func(_ context.Context, _ EventType, key string, value []byte) (string, []byte, error) {
+		return strings.TrimSuffix(key, ":draft"), value, nil
+	}, HookOptions{})
+	defer unregister()
+
+	ctx := context.Background()
+
+	// Conditional operations are bound to the version of their key, which a pre-hook cannot change
+	if _, err := c.SetIfAbsent(ctx, "a:draft", "x"); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("SetIfAbsent: expected ErrHookRejected, got %v", err)
+	}
+	if _, err := Update(ctx, c, "a:draft", func(_ *string) (string, error) { return "x", nil }); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("Update: expected ErrHookRejected, got %v", err)
+	}
+	if err := c.DeleteIfVersion(ctx, "a:draft", 1); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("DeleteIfVersion: expected ErrHookRejected, got %v", err)
+	}
+
+	// Keys left unchanged are written as usual
+	if _, err := c.SetIfAbsent(ctx, "a", "x"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Two values of a batch cannot be moved to the same key
+	if err := c.BatchSet(ctx, map[string]any{"b": "1", "b:draft": "2"}); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("BatchSet: expected ErrHookRejected, got %v", err)
+	}
+	if _, ok := mockKV.Data["b"]; ok {
+		t.Error("expected the batch not to be written")
+	}
+
+	mockKV.Data["c"] = []byte(`"1"`)
+	mockKV.Data["c:draft"] = []byte(`"2"`)
+
+	if err := c.BatchDelete(ctx, []string{"c", "c:draft"}); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("BatchDelete: expected ErrHookRejected, got %v", err)
+	}
+	if _, ok := mockKV.Data["c"]; !ok {
+		t.Error("expected the batch not to be deleted")
+	}
+}
+
+func TestClient_PreHookReject(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errReadOnly := errors.New("read-only key")
+	veto := func(_ context.Context, _ EventType, key string, value []byte) (string, []byte, error) {
+		if strings.HasPrefix(key, "readonly:") {
+			return "", nil, errReadOnly
+		}
+		return key, value, nil
+	}
+
+	_, _, unregister := c.RegisterPreHook(veto, HookOptions{FailOperation: true})
+	defer unregister()
+
+	ctx := context.Background()
+
+	err = c.Set(ctx, "readonly:a", "value")
+	if !errors.Is(err, errs.ErrHookRejected) || !errors.Is(err, errReadOnly) {
+		t.Errorf("expected ErrHookRejected wrapping the hook error, got %v", err)
+	}
+	if len(mockKV.Data) != 0 {
+		t.Errorf("expected nothing to be written, got %v", mockKV.Data)
+	}
+
+	err = c.BatchSet(ctx, map[string]any{"a": "1", "readonly:b": "2"})
+	if !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("expected ErrHookRejected, got %v", err)
+	}
+	if len(mockKV.Data) != 0 {
+		t.Errorf("expected the whole batch to be rejected, got %v", mockKV.Data)
+	}
+
+	mockKV.Data["readonly:a"] = []byte(`"value"`)
+	if err := c.Delete(ctx, "readonly:a"); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("expected ErrHookRejected, got %v", err)
+	}
+	if err := c.Txn(ctx, func(tx Tx) error { return tx.Delete("readonly:a") }); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("expected ErrHookRejected, got %v", err)
+	}
+	if _, ok := mockKV.Data["readonly:a"]; !ok {
+		t.Error("expected the key not to be deleted")
+	}
+
+	// Pre-hooks are skipped along with hooks
+	if err := c.Set(ctx, "readonly:b", "value", WithoutHooks()); err != nil {
+		t.Errorf("expected pre-hooks to be skipped, got %v", err)
+	}
+}
+
+func TestClient_PreHookErrorsReported(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, errCh, unregister := c.RegisterPreHook(func(_ context.Context, _ EventType, _ string, _ []byte) (string, []byte, error) {
+		return "other", nil, errors.New("hook error")
+	}, HookOptions{})
+	defer unregister()
+
+	if err := c.Set(context.Background(), "a", "value"); err != nil {
+		t.Fatalf("expected the operation to succeed, got %v", err)
+	}
+	if got := string(mockKV.Data["a"]); got != `"value"` {
+		t.Errorf("got %q, want %q", got, `"value"`)
+	}
+
+	select {
+	case err := <-errCh:
+		var hookErr *HookError
+		if !errors.As(err, &hookErr) || hookErr.Event != EventBeforeSet || hookErr.Key != "a" || hookErr.Err.Error() != "hook error" {
+			t.Errorf("unexpected error %v", err)
+		}
+	default:
+		t.Error("expected the hook error to be reported")
+	}
+}
+
+func TestClient_PreHookNamespace(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New(), KeyPolicy: KeyPolicy{MaxLength: 16}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var seen []string
+	_, _, unregister := c.RegisterPreHook(func(_ context.Context, _ EventType, key string, value []byte) (string, []byte, error) {
+		seen = append(seen, key)
+		return strings.Replace(key, "tmp", "archive", 1), value, nil
+	}, HookOptions{})
+	defer unregister()
+
+	billing := c.WithNamespace("billing")
+	ctx := context.Background()
+
+	if err := billing.Set(ctx, "tmp", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mockKV.Data["billing:archive"]; !ok {
+		t.Errorf("expected the key rewritten by the outer pre-hook, got %v", mockKV.Data)
+	}
+	if !slices.Equal(seen, []string{"billing:tmp"}) {
+		t.Errorf("got keys %v, want %v", seen, []string{"billing:tmp"})
+	}
+
+	// Rewritten keys are checked against the key policy
+	if err := billing.Set(ctx, "tmp:123456789", "value"); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+
+	_, _, unregisterEscape := c.RegisterPreHook(func(_ context.Context, _ EventType, key string, value []byte) (string, []byte, error) {
+		return strings.TrimPrefix(key, "billing:"), value, nil
+	}, HookOptions{Priority: -1})
+	defer unregisterEscape()
+
+	if err := billing.Set(ctx, "a", "value"); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("expected ErrHookRejected, got %v", err)
+	}
+}
+
+func TestClient_ReadHooks(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+
+	_, _, unregister := c.RegisterHook(func(_ context.Context, evt EventType, key string, _ []byte) error {
+		events = append(events, string(evt)+" "+key)
+		return nil
+	}, HookOptions{Events: []EventType{EventGet, EventMiss, EventList}})
+	defer unregister()
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "a", &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(ctx, "missing", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := c.BatchGet(ctx, []string{"a"}, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HasKey(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetRaw(ctx, "missing"); !errors.Is(err, errs.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := c.List(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"GET a", "MISS missing", "GET a", "GET a", "MISS missing", "LIST a"}
+	if !slices.Equal(events, want) {
+		t.Errorf("got events %v, want %v", events, want)
+	}
+}
+
+func TestClient_RawHooks(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+
+	_, _, unregister := c.RegisterHook(func(_ context.Context, evt EventType, key string, value []byte) error {
+		events = append(events, string(evt)+" "+key+" "+string(value))
+		return nil
+	}, HookOptions{Events: []EventType{EventSetRaw, EventDeleteRaw}})
+	defer unregister()
+
+	_, _, unregisterPre := c.RegisterPreHook(func(_ context.Context, _ EventType, key string, value []byte) (string, []byte, error) {
+		if key == "readonly" {
+			return "", nil, errors.New("read-only key")
+		}
+		return key, value, nil
+	}, HookOptions{FailOperation: true})
+	defer unregisterPre()
+
+	ctx := context.Background()
+
+	if err := c.SetRaw(ctx, "a", []byte("raw")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(mockKV.Data["a"]); got != "raw" {
+		t.Errorf("got %q, want %q", got, "raw")
+	}
+	if err := c.DeleteRaw(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mockKV.Data["a"]; ok {
+		t.Error("expected the key to be deleted")
+	}
+
+	if err := c.SetRaw(ctx, "readonly", []byte("raw")); !errors.Is(err, errs.ErrHookRejected) {
+		t.Errorf("expected ErrHookRejected, got %v", err)
+	}
+	if err := c.SetRaw(ctx, "", []byte("raw")); !errors.Is(err, errs.ErrEmptyKey) {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+	if _, err := c.GetRaw(ctx, ""); !errors.Is(err, errs.ErrEmptyKey) {
+		t.Errorf("expected ErrEmptyKey, got %v", err)
+	}
+
+	want := []string{"SET_RAW a raw", "DELETE_RAW a "}
+	if !slices.Equal(events, want) {
+		t.Errorf("got events %v, want %v", events, want)
+	}
+}
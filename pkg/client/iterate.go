@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// defaultPageSize is the number of entries fetched per page by Iterate when IterateOptions.PageSize is zero.
+const defaultPageSize = 100
+
+// IterateOptions configures Client.Iterate.
+type IterateOptions struct {
+	// Prefix restricts the iteration to keys starting with this prefix.
+	Prefix string
+
+	// Start is the inclusive lower bound of the iterated keys.
+	// If empty, there is no lower bound.
+	Start string
+
+	// End is the exclusive upper bound of the iterated keys.
+	// If empty, there is no upper bound.
+	End string
+
+	// PageSize is the number of entries fetched from the backend at once.
+	// Default: 100
+	PageSize int
+}
+
+// Entry is a key yielded by Client.Iterate. Its value is decoded on demand with Decode.
+type Entry struct {
+	// Key is the key of the entry, without the key prefix of the client.
+	Key string
+
+	client Client
+	stored string // key as stored in the backend
+	raw    []byte
+	loaded bool
+}
+
+// Decode decodes the value of the entry into value.
+// For backends that do not implement models.KVWithScan, the value is fetched on first call.
+func (e *Entry) Decode(ctx context.Context, value any) error {
+	if !e.loaded {
+		raw, err := e.client.KV.GetRaw(ctx, e.stored)
+		if err != nil {
+			return err
+		}
+
+		e.raw = raw
+		e.loaded = true
+	}
+
+	raw, err := stripTTL(e.raw)
+	if err != nil {
+		return err
+	}
+
+	return e.client.opts.decode(ctx, raw, value)
+}
+
+// Iterate returns an iterator over the keys matching the given options, in lexicographic order.
+// Entries are fetched page by page so that large key spaces are never loaded in memory at once.
+// If an error occurs, it is yielded with a nil Entry and the iteration stops.
+//
+// Backends implementing models.KVWithScan are scanned with a cursor. For other backends,
+// the keys are listed at once and the values are fetched lazily when decoded.
+//
+// Example:
+//
+//	for entry, err := range client.Iterate(ctx, client.IterateOptions{Prefix: "user:"}) {
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    var u User
+//	    if err := entry.Decode(ctx, &u); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    fmt.Println(entry.Key, u)
+//	}
+func (c Client) Iterate(ctx context.Context, opts IterateOptions) iter.Seq2[*Entry, error] {
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+
+	opts.Prefix = c.opts.key(opts.Prefix)
+	if opts.Start != "" {
+		opts.Start = c.opts.key(opts.Start)
+	}
+	if opts.End != "" {
+		opts.End = c.opts.key(opts.End)
+	}
+
+	if scanner, ok := c.KV.(models.KVWithScan); ok {
+		return c.iterateScan(ctx, scanner, opts)
+	}
+
+	return c.iterateList(ctx, opts)
+}
+
+// iterateScan iterates over the keys using the backend cursor.
+func (c Client) iterateScan(ctx context.Context, scanner models.KVWithScan, opts IterateOptions) iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		scanOpts := models.ScanOptions{
+			Prefix: opts.Prefix,
+			Limit:  opts.PageSize,
+			Start:  opts.Start,
+			End:    opts.End,
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			page, err := scanner.ScanRaw(ctx, scanOpts)
+			if errors.Is(err, errs.ErrOperationNotSupported) && scanOpts.Cursor == "" {
+				// Wrappers such as caches implement models.KVWithScan whether or not the backend they wrap does
+				c.iterateList(ctx, opts)(yield)
+				return
+			}
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to scan keys: %w", err))
+				return
+			}
+
+			for _, e := range page.Entries {
+				// Expired values are skipped
+				if _, err := stripTTL(e.Value); err != nil {
+					continue
+				}
+
+				if !yield(c.entry(e.Key, e.Value, true), nil) {
+					return
+				}
+			}
+
+			if page.Cursor == "" {
+				return
+			}
+
+			scanOpts.Cursor = page.Cursor
+		}
+	}
+}
+
+// iterateList iterates over the keys returned by List.
+func (c Client) iterateList(ctx context.Context, opts IterateOptions) iter.Seq2[*Entry, error] {
+	return func(yield func(*Entry, error) bool) {
+		keys, err := c.KV.List(ctx, opts.Prefix)
+		if err != nil {
+			yield(nil, fmt.Errorf("failed to list keys: %w", err))
+			return
+		}
+
+		slices.Sort(keys)
+
+		for i, key := range keys {
+			if opts.Start != "" && key < opts.Start {
+				continue
+			}
+			if opts.End != "" && key >= opts.End {
+				return
+			}
+
+			if i%opts.PageSize == 0 {
+				if err := ctx.Err(); err != nil {
+					yield(nil, err)
+					return
+				}
+			}
+
+			if !yield(c.entry(key, nil, false), nil) {
+				return
+			}
+		}
+	}
+}
+
+// entry returns the entry of the key as stored in the backend.
+func (c Client) entry(key string, raw []byte, loaded bool) *Entry {
+	return &Entry{Key: c.opts.unprefixed(key), client: c, stored: key, raw: raw, loaded: loaded}
+}
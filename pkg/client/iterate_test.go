@@ -0,0 +1,171 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func Test_Iterate(t *testing.T) {
+	newMockKV := func() *mock.MockKV {
+		mockKV := &mock.MockKV{Data: map[string][]byte{}}
+		for i := range 25 {
+			mockKV.Data[fmt.Sprintf("user:%02d", i)] = []byte(fmt.Sprintf(`"value-%02d"`, i))
+		}
+		mockKV.Data["other"] = []byte(`"other"`)
+
+		return mockKV
+	}
+
+	backends := map[string]func() client.Client{
+		"scan": func() client.Client {
+			c, err := client.New(newMockKV(), client.Option{Encoder: json.New()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return c
+		},
+		"list fallback": func() client.Client {
+			c, err := client.New(plainKV{KV: newMockKV()}, client.Option{Encoder: json.New()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return c
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     client.IterateOptions
+		wantKeys []string
+	}{
+		{
+			name:     "prefix across pages",
+			opts:     client.IterateOptions{Prefix: "user:", PageSize: 10},
+			wantKeys: userKeys(0, 25),
+		},
+		{
+			name:     "bounds",
+			opts:     client.IterateOptions{Prefix: "user:", Start: "user:05", End: "user:08", PageSize: 2},
+			wantKeys: userKeys(5, 8),
+		},
+		{
+			name:     "no match",
+			opts:     client.IterateOptions{Prefix: "missing:"},
+			wantKeys: nil,
+		},
+	}
+
+	for backend, newClient := range backends {
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				c := newClient()
+				ctx := context.Background()
+
+				var keys []string
+
+				for entry, err := range c.Iterate(ctx, tt.opts) {
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					var value string
+					if err := entry.Decode(ctx, &value); err != nil {
+						t.Fatal(err)
+					}
+					if want := "value-" + entry.Key[len("user:"):]; value != want {
+						t.Errorf("Decode(%s) = %q, want %q", entry.Key, value, want)
+					}
+
+					keys = append(keys, entry.Key)
+				}
+
+				if !reflect.DeepEqual(keys, tt.wantKeys) {
+					t.Errorf("Iterate() keys = %v, want %v", keys, tt.wantKeys)
+				}
+			})
+		}
+	}
+}
+
+func Test_Iterate_Break(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	for i := range 10 {
+		mockKV.Data[fmt.Sprintf("user:%02d", i)] = []byte(`"value"`)
+	}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+
+	for _, err := range c.Iterate(context.Background(), client.IterateOptions{PageSize: 3}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		count++
+		if count == 4 {
+			break
+		}
+	}
+
+	if count != 4 {
+		t.Errorf("expected iteration to stop after 4 entries, got %d", count)
+	}
+}
+
+func Test_Iterate_Errors(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{"key": []byte(`"value"`)}}
+
+	c, err := client.New(plainKV{KV: mockKV}, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	// Keys removed after being listed are reported when decoded
+	for entry, err := range c.Iterate(ctx, client.IterateOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		delete(mockKV.Data, entry.Key)
+
+		var value string
+		if err := entry.Decode(ctx, &value); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	mockKV.Data["key"] = []byte(`"value"`)
+
+	for _, err := range c.Iterate(canceled, client.IterateOptions{}) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	}
+}
+
+func userKeys(from, to int) []string {
+	keys := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		keys = append(keys, fmt.Sprintf("user:%02d", i))
+	}
+
+	return keys
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// KeyPolicy restricts the keys accepted by a client.
+//
+// Keys are checked as stored in the backend, i.e. with the key prefix of the options, before any operation
+// reaches the backend. Backends implementing models.KVWithKeyLimits add their own limits to the policy.
+// Invalid keys are rejected with errs.ErrInvalidKey.
+//
+// Example:
+//
+//	c, err := client.New(backend, client.Option{
+//	    Encoder: json.New(),
+//	    KeyPolicy: client.KeyPolicy{
+//	        MaxLength:        250,
+//	        Charset:          func(r rune) bool { return r > ' ' && r < unicode.MaxASCII },
+//	        ReservedPrefixes: []string{"_internal:"},
+//	    },
+//	})
+type KeyPolicy struct {
+	// MaxLength is the maximum length of keys in bytes.
+	// If zero, only the limit of the backend applies, if any.
+	MaxLength int
+
+	// Charset reports whether a character is allowed in keys.
+	// If nil, all the characters the backend accepts are allowed.
+	Charset func(r rune) bool
+
+	// ReservedPrefixes are prefixes that keys cannot start with, e.g. the prefixes of internal keys.
+	ReservedPrefixes []string
+
+	// Validate is an additional check of the keys. Its error is wrapped with errs.ErrInvalidKey.
+	Validate func(key string) error
+}
+
+// validateKey checks the key as stored in the backend against the key policy of the options
+// and the limits of the backend.
+func (c Client) validateKey(o Option, key string) error {
+	p := o.KeyPolicy
+
+	maxLength := p.MaxLength
+	var forbidden string
+
+	if kv, ok := c.KV.(models.KVWithKeyLimits); ok {
+		limits := kv.KeyLimits()
+		if limits.MaxLength > 0 && (maxLength <= 0 || limits.MaxLength < maxLength) {
+			maxLength = limits.MaxLength
+		}
+		forbidden = limits.ForbiddenChars
+	}
+
+	if maxLength > 0 && len(key) > maxLength {
+		return fmt.Errorf("%w: key %q is longer than %d bytes", errs.ErrInvalidKey, key, maxLength)
+	}
+
+	if forbidden != "" || p.Charset != nil {
+		for _, r := range key {
+			if strings.ContainsRune(forbidden, r) || (p.Charset != nil && !p.Charset(r)) {
+				return fmt.Errorf("%w: key %q contains the forbidden character %q", errs.ErrInvalidKey, key, r)
+			}
+		}
+	}
+
+	for _, prefix := range p.ReservedPrefixes {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return fmt.Errorf("%w: key %q uses the reserved prefix %q", errs.ErrInvalidKey, key, prefix)
+		}
+	}
+
+	if p.Validate != nil {
+		if err := p.Validate(key); err != nil {
+			return fmt.Errorf("%w: key %q: %w", errs.ErrInvalidKey, key, err)
+		}
+	}
+
+	return nil
+}
+
+// validateKeys checks the keys as stored in the backend. See validateKey.
+func (c Client) validateKeys(o Option, keys []string) error {
+	for _, key := range keys {
+		if err := c.validateKey(o, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+func TestClient_KeyPolicy(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := New(mockKV, Option{
+		Encoder: json.New(),
+		KeyPolicy: KeyPolicy{
+			MaxLength:        12,
+			Charset:          func(r rune) bool { return r != ' ' },
+			ReservedPrefixes: []string{"_sys:"},
+			Validate: func(key string) error {
+				if strings.HasSuffix(key, ":") {
+					return errors.New("trailing separator")
+				}
+				return nil
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "user:42", "alice"); err != nil {
+		t.Fatalf("unexpected error for a valid key: %v", err)
+	}
+
+	for _, key := range []string{"user:1234567890", "user 42", "_sys:config", "user:"} {
+		if err := c.Set(ctx, key, "x"); !errors.Is(err, errs.ErrInvalidKey) {
+			t.Errorf("Set(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+		if err := c.Get(ctx, key, new(string)); !errors.Is(err, errs.ErrInvalidKey) {
+			t.Errorf("Get(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+		if err := c.Delete(ctx, key); !errors.Is(err, errs.ErrInvalidKey) {
+			t.Errorf("Delete(%q): expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+
+	// Every operation taking a key applies the policy
+	for name, op := range map[string]func(key string) error{
+		"GetRaw":  func(key string) error { _, err := c.GetRaw(ctx, key); return err },
+		"HasKey":  func(key string) error { _, err := c.HasKey(ctx, key); return err },
+		"TTL":     func(key string) error { _, err := c.TTL(ctx, key); return err },
+		"Persist": func(key string) error { return c.Persist(ctx, key) },
+		"GetWithVersion": func(key string) error {
+			_, err := c.GetWithVersion(ctx, key, new(string))
+			return err
+		},
+		"DeleteIfVersion": func(key string) error { return c.DeleteIfVersion(ctx, key, 1) },
+		"Txn.Get": func(key string) error {
+			return c.Txn(ctx, func(tx Tx) error { return tx.Get(key, new(string)) })
+		},
+		"Txn.Delete": func(key string) error {
+			return c.Txn(ctx, func(tx Tx) error { return tx.Delete(key) })
+		},
+		"Txn.RequireAbsent": func(key string) error {
+			return c.Txn(ctx, func(tx Tx) error { return tx.RequireAbsent(key) })
+		},
+	} {
+		if err := op("_sys:config"); !errors.Is(err, errs.ErrInvalidKey) {
+			t.Errorf("%s: expected ErrInvalidKey, got %v", name, err)
+		}
+	}
+
+	if err := c.BatchSet(ctx, map[string]any{"a": 1, "user 42": 2}); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("BatchSet: expected ErrInvalidKey, got %v", err)
+	}
+	if _, ok := mockKV.Data["a"]; ok {
+		t.Error("expected the batch to be rejected before reaching the backend")
+	}
+	if err := c.BatchGet(ctx, []string{"_sys:a"}, map[string]any{}); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("BatchGet: expected ErrInvalidKey, got %v", err)
+	}
+	if err := c.BatchDelete(ctx, []string{"user 42"}); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("BatchDelete: expected ErrInvalidKey, got %v", err)
+	}
+
+	// The key prefix counts towards the length
+	if err := c.Set(ctx, "42", "x", WithKeyPrefix("user:profile:")); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for a prefixed key, got %v", err)
+	}
+
+	// Per-call policies override the policy of the client
+	if err := c.Set(ctx, "user 42", "x", WithKeyPolicy(KeyPolicy{})); err != nil {
+		t.Errorf("unexpected error with an overridden policy: %v", err)
+	}
+}
+
+func TestClient_KeyPolicy_BackendLimits(t *testing.T) {
+	mockKV := &mock.MockKV{
+		Data:   map[string][]byte{},
+		Limits: models.KeyLimits{MaxLength: 10, ForbiddenChars: " \n"},
+	}
+
+	c, err := New(mockKV, Option{Encoder: json.New(), KeyPolicy: KeyPolicy{MaxLength: 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "0123456789a", "x"); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("expected the limit of the backend to apply, got %v", err)
+	}
+	if err := c.Set(ctx, "a\nb", "x"); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for a forbidden character, got %v", err)
+	}
+
+	// Namespaces reduce the maximum length of the keys
+	ns := c.WithNamespace("tenant")
+	if err := ns.Set(ctx, "012", "x"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ns.Set(ctx, "0123", "x"); !errors.Is(err, errs.ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey in the namespace, got %v", err)
+	}
+}
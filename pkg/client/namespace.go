@@ -0,0 +1,412 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// NamespaceSeparator separates a namespace from the keys it contains.
+const NamespaceSeparator = ":"
+
+var (
+	_ models.KV              = (*namespacedKV)(nil)
+	_ models.KVWithBatch     = (*namespacedKV)(nil)
+	_ models.KVWithHealth    = (*namespacedKV)(nil)
+	_ models.KVWithKeyLimits = (*namespacedKV)(nil)
+	_ models.KVWithWatch     = (*namespacedKV)(nil)
+	_ models.KVWithTTL       = (*namespacedKV)(nil)
+	_ models.KVWithCAS       = (*namespacedKV)(nil)
+	_ models.KVWithTxn       = (*namespacedKV)(nil)
+	_ models.KVWithScan      = (*namespacedKV)(nil)
+)
+
+type (
+	// namespaceHooks are the hooks of an outer client, with the prefix its keys are stored under.
+	namespaceHooks struct {
+		hooks  *HooksRegistry
+		prefix string
+	}
+
+	// namespacedKV confines a backend to the keys starting with prefix.
+	// The capabilities of the backend are exposed with the prefix added to the keys they take and stripped
+	// from the keys they return. Those the backend does not implement return errs.ErrOperationNotSupported,
+	// except batches and health checks which are emulated.
+	namespacedKV struct {
+		kv     models.KV
+		prefix string
+	}
+)
+
+// WithNamespace returns a view of the client confined to the namespace ns.
+//
+// Keys are transparently prefixed with ns followed by NamespaceSeparator, and the prefix is stripped from
+// List results, so that List(ctx, "") only returns the keys of the namespace. Namespaces can be nested.
+//
+// The view has its own hooks, which receive the keys without the prefix. Hooks registered on the outer
+// clients are still triggered by the writes of the view, with the keys as seen by those clients.
+//
+// The capabilities of the backend, such as expirations, compare-and-swap, transactions, scans and watches, are
+// available through the view and confined to the namespace. Closing the view does not close the backend.
+//
+// Example:
+//
+//	billing := client.WithNamespace("billing")
+//	err := billing.Set(ctx, "invoice:42", invoice) // stored under "billing:invoice:42"
+func (c Client) WithNamespace(ns string) Client {
+	if ns == "" {
+		return c
+	}
+
+	prefix := ns + NamespaceSeparator
+
+	outer := make([]namespaceHooks, 0, len(c.outerHooks)+1)
+	if c.hooks != nil {
+		outer = append(outer, namespaceHooks{hooks: c.hooks, prefix: prefix})
+	}
+	for _, o := range c.outerHooks {
+		outer = append(outer, namespaceHooks{hooks: o.hooks, prefix: o.prefix + prefix})
+	}
+
+	return Client{
+		KV:         &namespacedKV{kv: c.KV, prefix: prefix},
+		opts:       c.opts,
+		hooks:      NewHooksRegistry(),
+		outerHooks: outer,
+	}
+}
+
+// Namespace returns the full namespace of the client, or an empty string if the client is not namespaced.
+func (c Client) Namespace() string {
+	var prefix string
+
+	for kv := c.KV; ; {
+		ns, ok := kv.(*namespacedKV)
+		if !ok {
+			break
+		}
+
+		prefix = ns.prefix + prefix
+		kv = ns.kv
+	}
+
+	return strings.TrimSuffix(prefix, NamespaceSeparator)
+}
+
+func (n *namespacedKV) GetRaw(ctx context.Context, key string) ([]byte, error) {
+	return n.kv.GetRaw(ctx, n.prefix+key)
+}
+
+func (n *namespacedKV) SetRaw(ctx context.Context, key string, value []byte) error {
+	return n.kv.SetRaw(ctx, n.prefix+key, value)
+}
+
+func (n *namespacedKV) Delete(ctx context.Context, key string) error {
+	return n.kv.Delete(ctx, n.prefix+key)
+}
+
+// List lists the keys of the namespace starting with prefix, without the namespace prefix.
+func (n *namespacedKV) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := n.kv.List(ctx, n.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(keys))
+	for _, k := range keys {
+		// Defensive: backends are expected to only return keys starting with the prefix
+		if key, ok := strings.CutPrefix(k, n.prefix); ok {
+			result = append(result, key)
+		}
+	}
+
+	return result, nil
+}
+
+// Close does nothing: the backend is shared with the outer client.
+func (n *namespacedKV) Close() error {
+	return nil
+}
+
+// Health checks the health of the backend if it implements models.KVWithHealth.
+func (n *namespacedKV) Health(ctx context.Context) error {
+	if h, ok := n.kv.(models.KVWithHealth); ok {
+		return h.Health(ctx)
+	}
+
+	return nil
+}
+
+// KeyLimits returns the key limits of the backend, if it implements models.KVWithKeyLimits,
+// with the maximum length reduced by the length of the prefix.
+func (n *namespacedKV) KeyLimits() models.KeyLimits {
+	kl, ok := n.kv.(models.KVWithKeyLimits)
+	if !ok {
+		return models.KeyLimits{}
+	}
+
+	limits := kl.KeyLimits()
+	if limits.MaxLength > 0 {
+		limits.MaxLength -= len(n.prefix)
+	}
+
+	return limits
+}
+
+// WatchRaw reports the changes made to the keys of the namespace starting with prefix, without the namespace prefix,
+// if the backend implements models.KVWithWatch.
+func (n *namespacedKV) WatchRaw(ctx context.Context, prefix string) (<-chan models.WatchEvent, error) {
+	watcher, ok := n.kv.(models.KVWithWatch)
+	if !ok {
+		return nil, fmt.Errorf("watch not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	raws, err := watcher.WatchRaw(ctx, n.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan models.WatchEvent)
+
+	go func() {
+		defer close(ch)
+
+		for evt := range raws {
+			key, ok := strings.CutPrefix(evt.Key, n.prefix)
+			if !ok {
+				// Defensive: backends are expected to only report keys starting with the prefix
+				continue
+			}
+			evt.Key = key
+
+			select {
+			case ch <- evt:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// SetRawWithTTL writes the value with an expiration if the backend implements models.KVWithTTL.
+func (n *namespacedKV) SetRawWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	kv, err := n.ttl()
+	if err != nil {
+		return err
+	}
+
+	return kv.SetRawWithTTL(ctx, n.prefix+key, value, ttl)
+}
+
+// TTL returns the remaining time to live of the key if the backend implements models.KVWithTTL.
+func (n *namespacedKV) TTL(ctx context.Context, key string) (time.Duration, error) {
+	kv, err := n.ttl()
+	if err != nil {
+		return 0, err
+	}
+
+	return kv.TTL(ctx, n.prefix+key)
+}
+
+// Persist removes the expiration of the key if the backend implements models.KVWithTTL.
+func (n *namespacedKV) Persist(ctx context.Context, key string) error {
+	kv, err := n.ttl()
+	if err != nil {
+		return err
+	}
+
+	return kv.Persist(ctx, n.prefix+key)
+}
+
+// GetRawWithVersion retrieves the value of the key and its version if the backend implements models.KVWithCAS.
+func (n *namespacedKV) GetRawWithVersion(ctx context.Context, key string) ([]byte, uint64, error) {
+	cas, err := n.cas()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return cas.GetRawWithVersion(ctx, n.prefix+key)
+}
+
+// SetRawIfVersion writes the value if the version of the key matches and the backend implements models.KVWithCAS.
+func (n *namespacedKV) SetRawIfVersion(ctx context.Context, key string, value []byte, version uint64) (uint64, error) {
+	cas, err := n.cas()
+	if err != nil {
+		return 0, err
+	}
+
+	return cas.SetRawIfVersion(ctx, n.prefix+key, value, version)
+}
+
+// SetRawIfAbsent writes the value if the key does not exist and the backend implements models.KVWithCAS.
+func (n *namespacedKV) SetRawIfAbsent(ctx context.Context, key string, value []byte) (uint64, error) {
+	cas, err := n.cas()
+	if err != nil {
+		return 0, err
+	}
+
+	return cas.SetRawIfAbsent(ctx, n.prefix+key, value)
+}
+
+// DeleteIfVersion removes the key if its version matches and the backend implements models.KVWithCAS.
+func (n *namespacedKV) DeleteIfVersion(ctx context.Context, key string, version uint64) error {
+	cas, err := n.cas()
+	if err != nil {
+		return err
+	}
+
+	return cas.DeleteIfVersion(ctx, n.prefix+key, version)
+}
+
+// CommitTxn commits the operations with their keys prefixed if the backend implements models.KVWithTxn.
+func (n *namespacedKV) CommitTxn(ctx context.Context, ops []models.TxnOp) error {
+	kv, ok := n.kv.(models.KVWithTxn)
+	if !ok {
+		return fmt.Errorf("transactions not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	prefixed := make([]models.TxnOp, len(ops))
+	for i, op := range ops {
+		op.Key = n.prefix + op.Key
+		prefixed[i] = op
+	}
+
+	return kv.CommitTxn(ctx, prefixed)
+}
+
+// ScanRaw returns a page of the entries of the namespace, without the namespace prefix,
+// if the backend implements models.KVWithScan. The cursor is passed through unchanged.
+func (n *namespacedKV) ScanRaw(ctx context.Context, opts models.ScanOptions) (models.ScanPage, error) {
+	scanner, ok := n.kv.(models.KVWithScan)
+	if !ok {
+		return models.ScanPage{}, fmt.Errorf("scan not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	// Empty bounds stay unbounded, the prefix already confines the scan to the namespace
+	opts.Prefix = n.prefix + opts.Prefix
+	if opts.Start != "" {
+		opts.Start = n.prefix + opts.Start
+	}
+	if opts.End != "" {
+		opts.End = n.prefix + opts.End
+	}
+
+	page, err := scanner.ScanRaw(ctx, opts)
+	if err != nil {
+		return models.ScanPage{}, err
+	}
+
+	entries := make([]models.ScanEntry, 0, len(page.Entries))
+	for _, e := range page.Entries {
+		// Defensive: backends are expected to only return keys starting with the prefix
+		if key, ok := strings.CutPrefix(e.Key, n.prefix); ok {
+			e.Key = key
+			entries = append(entries, e)
+		}
+	}
+	page.Entries = entries
+
+	return page, nil
+}
+
+// BatchGetRaw retrieves the values of the keys in a single batch if the backend implements models.KVWithBatch.
+func (n *namespacedKV) BatchGetRaw(ctx context.Context, keys []string) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+
+	batch, ok := n.kv.(models.KVWithBatch)
+	if !ok {
+		for _, k := range keys {
+			value, err := n.GetRaw(ctx, k)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = value
+		}
+
+		return result, nil
+	}
+
+	raws, err := batch.BatchGetRaw(ctx, n.keys(keys))
+	if err != nil {
+		return nil, err
+	}
+
+	for k, value := range raws {
+		result[strings.TrimPrefix(k, n.prefix)] = value
+	}
+
+	return result, nil
+}
+
+// BatchSetRaw writes the values in a single batch if the backend implements models.KVWithBatch.
+func (n *namespacedKV) BatchSetRaw(ctx context.Context, kv map[string][]byte) error {
+	batch, ok := n.kv.(models.KVWithBatch)
+	if !ok {
+		for k, v := range kv {
+			if err := n.SetRaw(ctx, k, v); err != nil {
+				return fmt.Errorf("failed to set key %s: %w", k, err)
+			}
+		}
+
+		return nil
+	}
+
+	prefixed := make(map[string][]byte, len(kv))
+	for k, v := range kv {
+		prefixed[n.prefix+k] = v
+	}
+
+	return batch.BatchSetRaw(ctx, prefixed)
+}
+
+// BatchDelete removes the keys in a single batch if the backend implements models.KVWithBatch.
+func (n *namespacedKV) BatchDelete(ctx context.Context, keys []string) error {
+	batch, ok := n.kv.(models.KVWithBatch)
+	if !ok {
+		for _, k := range keys {
+			if err := n.Delete(ctx, k); err != nil {
+				return fmt.Errorf("failed to delete key %s: %w", k, err)
+			}
+		}
+
+		return nil
+	}
+
+	return batch.BatchDelete(ctx, n.keys(keys))
+}
+
+// ttl returns the backend as a models.KVWithTTL.
+func (n *namespacedKV) ttl() (models.KVWithTTL, error) {
+	kv, ok := n.kv.(models.KVWithTTL)
+	if !ok {
+		return nil, fmt.Errorf("ttl not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	return kv, nil
+}
+
+// cas returns the backend as a models.KVWithCAS.
+func (n *namespacedKV) cas() (models.KVWithCAS, error) {
+	cas, ok := n.kv.(models.KVWithCAS)
+	if !ok {
+		return nil, fmt.Errorf("compare-and-swap not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	return cas, nil
+}
+
+// keys returns the keys prefixed with the namespace.
+func (n *namespacedKV) keys(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = n.prefix + k
+	}
+
+	return prefixed
+}
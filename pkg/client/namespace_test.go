@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func TestClient_WithNamespace(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	root, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	billing := root.WithNamespace("billing")
+	shipping := root.WithNamespace("shipping")
+
+	if err := billing.Set(ctx, "invoice:1", "100"); err != nil {
+		t.Fatal(err)
+	}
+	if err := shipping.Set(ctx, "invoice:1", "200"); err != nil {
+		t.Fatal(err)
+	}
+	if err := billing.BatchSet(ctx, map[string]any{"invoice:2": "300"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"billing:invoice:1", "billing:invoice:2", "shipping:invoice:1"} {
+		if _, ok := mockKV.Data[k]; !ok {
+			t.Errorf("expected key %q to be stored, got %v", k, mockKV.Data)
+		}
+	}
+
+	var got string
+	if err := billing.Get(ctx, "invoice:1", &got); err != nil || got != "100" {
+		t.Errorf("Get() = (%q, %v), want %q", got, err, "100")
+	}
+
+	keys, err := billing.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"invoice:1", "invoice:2"}) {
+		t.Errorf("List() = %v, want the keys of the namespace only", keys)
+	}
+
+	values := map[string]string{}
+	if err := billing.BatchGet(ctx, []string{"invoice:1", "invoice:2"}, values); err != nil {
+		t.Fatal(err)
+	}
+	if values["invoice:1"] != "100" || values["invoice:2"] != "300" {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	if err := billing.BatchDelete(ctx, []string{"invoice:1", "invoice:2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := shipping.Get(ctx, "invoice:1", &got); err != nil {
+		t.Errorf("expected the other namespace to be untouched, got %v", err)
+	}
+
+	if err := billing.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_WithNamespace_Nested(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	root, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	team := root.WithNamespace("team")
+	svc := team.WithNamespace("svc")
+
+	if got := svc.Namespace(); got != "team:svc" {
+		t.Errorf("Namespace() = %q, want %q", got, "team:svc")
+	}
+	if got := root.Namespace(); got != "" {
+		t.Errorf("Namespace() = %q, want an empty namespace", got)
+	}
+
+	hooked := map[string]string{}
+	record := func(name string) HookFunc {
+		return func(_ context.Context, _ EventType, key string, _ []byte) error {
+			hooked[name] = key
+			return nil
+		}
+	}
+
+	root.RegisterHook(record("root"), HookOptions{})
+	team.RegisterHook(record("team"), HookOptions{})
+	svc.RegisterHook(record("svc"), HookOptions{})
+
+	if err := svc.Set(ctx, "k", "v"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := mockKV.Data["team:svc:k"]; !ok {
+		t.Errorf("expected nested prefix, got %v", mockKV.Data)
+	}
+
+	want := map[string]string{"root": "team:svc:k", "team": "svc:k", "svc": "k"}
+	for name, key := range want {
+		if hooked[name] != key {
+			t.Errorf("hook of %s received %q, want %q", name, hooked[name], key)
+		}
+	}
+
+	clear(hooked)
+
+	if err := root.Set(ctx, "team:svc:other", "v"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hooked["svc"]; ok {
+		t.Error("expected hooks of a view not to be triggered by outer clients")
+	}
+
+	keys, err := team.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	if !slices.Equal(keys, []string{"svc:k", "svc:other"}) {
+		t.Errorf("List() = %v", keys)
+	}
+
+	if err := svc.Delete(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Delete(ctx, "k"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_WithNamespace_Capabilities(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}, Clock: func() time.Time { return current }}
+	root, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	billing := root.WithNamespace("billing")
+
+	// The backend expires the value natively instead of the client-side envelope
+	if err := billing.Set(ctx, "session", "token", WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if string(mockKV.Data["billing:session"]) != `"token"` {
+		t.Errorf("expected unwrapped value, got %q", mockKV.Data["billing:session"])
+	}
+	if ttl, err := billing.TTL(ctx, "session"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL() = (%v, %v), want %v", ttl, err, time.Minute)
+	}
+	if err := billing.Persist(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+	if ttl, err := mockKV.TTL(ctx, "billing:session"); err != nil || ttl != 0 {
+		t.Errorf("backend TTL() = (%v, %v), want no expiration", ttl, err)
+	}
+
+	if _, err := billing.SetIfAbsent(ctx, "lock", "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := root.SetIfAbsent(ctx, "lock", "other"); err != nil {
+		t.Errorf("expected the key outside the namespace to be absent, got %v", err)
+	}
+
+	var got string
+	version, err := billing.GetWithVersion(ctx, "lock", &got)
+	if err != nil || got != "owner" {
+		t.Fatalf("GetWithVersion() = (%q, %v), want %q", got, err, "owner")
+	}
+	if err := billing.DeleteIfVersion(ctx, "lock", version); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := billing.Txn(ctx, func(tx Tx) error {
+		if err := tx.RequireAbsent("lock"); err != nil {
+			return err
+		}
+		if err := tx.Set("invoice:1", "100"); err != nil {
+			return err
+		}
+		return tx.Set("invoice:2", "200")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"billing:invoice:1", "billing:invoice:2"} {
+		if _, ok := mockKV.Data[k]; !ok {
+			t.Errorf("expected key %q to be committed, got %v", k, mockKV.Data)
+		}
+	}
+
+	if err := root.Set(ctx, "invoice:0", "0"); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	for e, err := range billing.Iterate(ctx, IterateOptions{Prefix: "invoice:", Start: "invoice:1", PageSize: 1}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, e.Key)
+	}
+	if !slices.Equal(keys, []string{"invoice:1", "invoice:2"}) {
+		t.Errorf("Iterate() keys = %v, want the keys of the namespace only", keys)
+	}
+}
+
+func TestClient_WithNamespace_UnsupportedCapabilities(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	setClock(t, &current)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	root, err := New(basicKV{mockKV}, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	billing := root.WithNamespace("billing")
+
+	// The client falls back to its own expiration envelope
+	if err := billing.Set(ctx, "session", "token", WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := unwrapTTL(mockKV.Data["billing:session"]); !ok {
+		t.Errorf("expected value wrapped in an envelope, got %q", mockKV.Data["billing:session"])
+	}
+	if ttl, err := billing.TTL(ctx, "session"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL() = (%v, %v), want %v", ttl, err, time.Minute)
+	}
+
+	if _, err := billing.SetIfAbsent(ctx, "lock", "owner"); !errors.Is(err, errs.ErrOperationNotSupported) {
+		t.Errorf("expected ErrOperationNotSupported from SetIfAbsent, got %v", err)
+	}
+	if err := billing.Txn(ctx, func(tx Tx) error {
+		return tx.Set("invoice:1", "100")
+	}); !errors.Is(err, errs.ErrOperationNotSupported) {
+		t.Errorf("expected ErrOperationNotSupported from Txn, got %v", err)
+	}
+
+	var keys []string
+	for e, err := range billing.Iterate(ctx, IterateOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, e.Key)
+	}
+	if !slices.Equal(keys, []string{"session"}) {
+		t.Errorf("Iterate() keys = %v, want [session]", keys)
+	}
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	mencoder "github.com/kivigo/encoders/model"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+// WithEncoder returns an Options that overrides the encoder used to encode or decode values.
+//
+// Example:
+//
+//	err := client.Get(ctx, "config", &cfg, client.WithEncoder(yaml.New()))
+func WithEncoder(e mencoder.Encoder) Options {
+	return func(o Option) Option {
+		o.Encoder = e
+		return o
+	}
+}
+
+// WithoutHooks returns an Options that prevents the operation from triggering hooks.
+//
+// Example:
+//
+//	err := client.Set(ctx, "internal:state", state, client.WithoutHooks())
+func WithoutHooks() Options {
+	return func(o Option) Option {
+		o.SkipHooks = true
+		return o
+	}
+}
+
+// WithTimeout returns an Options that bounds the duration of the operation.
+// A zero or negative timeout disables the deadline.
+//
+// Example:
+//
+//	err := client.Get(ctx, "config", &cfg, client.WithTimeout(100*time.Millisecond))
+func WithTimeout(timeout time.Duration) Options {
+	return func(o Option) Option {
+		o.Timeout = timeout
+		return o
+	}
+}
+
+// WithKeyPrefix returns an Options that prepends prefix to the keys of the operation.
+// Keys returned to the caller, e.g. in the BatchGet destination map or by List, are not prefixed,
+// whereas hooks receive the keys as stored in the backend.
+//
+// When set in the client options, the prefix applies to every operation of the client.
+//
+// Example:
+//
+//	err := client.Set(ctx, "42", user, client.WithKeyPrefix("user:"))
+func WithKeyPrefix(prefix string) Options {
+	return func(o Option) Option {
+		o.KeyPrefix = prefix
+		return o
+	}
+}
+
+// WithKeyPolicy returns an Options that overrides the policy restricting the keys of the operation.
+//
+// Example:
+//
+//	err := client.Set(ctx, key, value, client.WithKeyPolicy(client.KeyPolicy{MaxLength: 64}))
+func WithKeyPolicy(p KeyPolicy) Options {
+	return func(o Option) Option {
+		o.KeyPolicy = p
+		return o
+	}
+}
+
+// callOptions returns the client options overridden by the per-call options.
+func (c Client) callOptions(opts []Options) Option {
+	o := c.opts
+	for _, opt := range opts {
+		o = opt(o)
+	}
+
+	return o
+}
+
+// context returns ctx bounded by the timeout of the options, if any.
+func (o Option) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, o.Timeout)
+}
+
+// key returns the key as stored in the backend.
+func (o Option) key(key string) string {
+	return o.KeyPrefix + key
+}
+
+// unprefixed returns the key as seen by the caller, without the key prefix.
+func (o Option) unprefixed(key string) string {
+	return strings.TrimPrefix(key, o.KeyPrefix)
+}
+
+// keys returns the keys as stored in the backend.
+func (o Option) keys(keys []string) []string {
+	if o.KeyPrefix == "" {
+		return keys
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, k := range keys {
+		prefixed[i] = o.key(k)
+	}
+
+	return prefixed
+}
+
+// runHooks triggers the hooks of the client unless disabled by the options.
+func (c Client) runHooks(ctx context.Context, o Option, evt EventType, key string, value []byte) {
+	if o.SkipHooks {
+		return
+	}
+
+	if c.hooks != nil {
+		c.hooks.Run(ctx, evt, key, value)
+	}
+
+	for _, outer := range c.outerHooks {
+		outer.hooks.Run(ctx, evt, outer.prefix+key, value)
+	}
+}
+
+// runBatchHooks triggers the hooks of the client for each key of a batch operation, with the ID of the batch
+// in their context, then the batch hooks, unless disabled by the options.
+func (c Client) runBatchHooks(ctx context.Context, o Option, evt EventType, keys []string, values map[string][]byte) {
+	if o.SkipHooks {
+		return
+	}
+
+	batch := newBatch(evt, keys, values)
+	ctx = context.WithValue(ctx, batchIDKey{}, batch.ID)
+
+	for _, e := range batch.Entries {
+		c.runHooks(ctx, o, evt, e.Key, e.Value)
+	}
+
+	if c.hooks != nil {
+		c.hooks.RunBatch(ctx, batch)
+	}
+
+	for _, outer := range c.outerHooks {
+		outer.hooks.RunBatch(ctx, batch.withPrefix(outer.prefix))
+	}
+}
+
+// runReadHooks triggers EventGet if the read of key returned value, or EventMiss if the key was not found.
+func (c Client) runReadHooks(ctx context.Context, o Option, key string, value []byte, err error) {
+	switch {
+	case err == nil:
+		c.runHooks(ctx, o, EventGet, key, value)
+	case errors.Is(err, errs.ErrNotFound):
+		c.runHooks(ctx, o, EventMiss, key, nil)
+	}
+}
+
+// runPreHooks triggers the pre-hooks of the client unless disabled by the options, and checks the key they return
+// if it was modified. The pre-hooks of the outer clients of a namespaced view run after those of the view and
+// cannot move the key out of the namespace.
+func (c Client) runPreHooks(ctx context.Context, o Option, evt EventType, key string, value []byte) (string, []byte, error) {
+	if o.SkipHooks {
+		return key, value, nil
+	}
+
+	origKey := key
+
+	if c.hooks != nil {
+		var err error
+		if key, value, err = c.hooks.RunPre(ctx, evt, key, value); err != nil {
+			return "", nil, err
+		}
+	}
+
+	for _, outer := range c.outerHooks {
+		fullKey, newValue, err := outer.hooks.RunPre(ctx, evt, outer.prefix+key, value)
+		if err != nil {
+			return "", nil, err
+		}
+
+		newKey, ok := strings.CutPrefix(fullKey, outer.prefix)
+		if !ok || newKey == "" {
+			return "", nil, fmt.Errorf("%w: hook moved key %q out of the namespace %q", errs.ErrHookRejected, fullKey, outer.prefix)
+		}
+		key, value = newKey, newValue
+	}
+
+	if key != origKey {
+		if err := c.validateKey(o, key); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return key, value, nil
+}
@@ -0,0 +1,317 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// rawStringEncoder stores strings as is, without JSON quoting.
+type rawStringEncoder struct{}
+
+func (rawStringEncoder) Encode(_ context.Context, v any) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+	return []byte(s), nil
+}
+
+func (rawStringEncoder) Decode(_ context.Context, data []byte, v any) error {
+	s, ok := v.(*string)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	*s = string(data)
+	return nil
+}
+
+// batchOnlyKV exposes the batch capability of the wrapped backend but not its TTL capability.
+type batchOnlyKV struct {
+	basicKV
+	models.KVWithBatch
+}
+
+// blockingKV blocks reads until the context is done.
+type blockingKV struct {
+	*mock.MockKV
+}
+
+func (b blockingKV) GetRaw(ctx context.Context, _ string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestClient_WithEncoder(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "raw", "value", WithEncoder(rawStringEncoder{})); err != nil {
+		t.Fatal(err)
+	}
+	if string(mockKV.Data["raw"]) != "value" {
+		t.Errorf("expected the overridden encoder to be used, got %q", mockKV.Data["raw"])
+	}
+
+	var got string
+	if err := c.Get(ctx, "raw", &got, WithEncoder(rawStringEncoder{})); err != nil {
+		t.Fatal(err)
+	}
+	if got != "value" {
+		t.Errorf("Get() = %q, want %q", got, "value")
+	}
+
+	// The override does not leak into later calls
+	if err := c.Get(ctx, "raw", &got); err == nil {
+		t.Error("expected the default encoder to fail decoding the raw value")
+	}
+
+	if err := c.BatchSet(ctx, map[string]any{"a": "1", "b": "2"}, WithEncoder(rawStringEncoder{})); err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]string{}
+	if err := c.BatchGet(ctx, []string{"a", "b"}, values, WithEncoder(rawStringEncoder{})); err != nil {
+		t.Fatal(err)
+	}
+	if values["a"] != "1" || values["b"] != "2" {
+		t.Errorf("unexpected values: %v", values)
+	}
+}
+
+func TestClient_WithTTL_Batch(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	setClock(t, &current)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}, Clock: func() time.Time { return current }}
+
+	for name, kv := range map[string]models.KV{
+		"native":   mockKV,
+		"envelope": batchOnlyKV{basicKV{mockKV}, mockKV},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := New(kv, Option{Encoder: json.New()})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+
+			if err := c.BatchSet(ctx, map[string]any{name: "1"}, WithTTL(time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if err := c.Get(ctx, name, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			current = current.Add(2 * time.Minute)
+
+			if err := c.Get(ctx, name, &got); !errors.Is(err, errs.ErrNotFound) {
+				t.Errorf("expected ErrNotFound after expiration, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_WithoutHooks(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []EventType
+
+	_, _, unregister := c.RegisterHook(func(_ context.Context, evt EventType, _ string, _ []byte) error {
+		events = append(events, evt)
+		return nil
+	}, HookOptions{})
+	defer unregister()
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "a", "1", WithoutHooks()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BatchSet(ctx, map[string]any{"b": "2"}, WithoutHooks()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BatchDelete(ctx, []string{"b"}, WithoutHooks()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, "a", WithoutHooks()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 0 {
+		t.Errorf("expected no hook to run, got %v", events)
+	}
+
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Errorf("expected hooks to run without the option, got %v", events)
+	}
+}
+
+func TestClient_WithTimeout(t *testing.T) {
+	c, err := New(blockingKV{&mock.MockKV{Data: map[string][]byte{}}}, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+
+	err = c.Get(context.Background(), "key", &got, WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestClient_WithKeyPrefix(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hooked []string
+
+	_, _, unregister := c.RegisterHook(func(_ context.Context, _ EventType, key string, _ []byte) error {
+		hooked = append(hooked, key)
+		return nil
+	}, HookOptions{})
+	defer unregister()
+
+	ctx := context.Background()
+	prefix := WithKeyPrefix("user:")
+
+	if err := c.Set(ctx, "1", "alice", prefix); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BatchSet(ctx, map[string]any{"2": "bob"}, prefix); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := mockKV.Data["user:1"]; !ok {
+		t.Errorf("expected prefixed key to be stored, got %v", mockKV.Data)
+	}
+
+	var got string
+	if err := c.Get(ctx, "1", &got, prefix); err != nil || got != "alice" {
+		t.Errorf("Get() = (%q, %v), want %q", got, err, "alice")
+	}
+
+	values := map[string]string{}
+	if err := c.BatchGet(ctx, []string{"1", "2"}, values, prefix); err != nil {
+		t.Fatal(err)
+	}
+	if values["1"] != "alice" || values["2"] != "bob" {
+		t.Errorf("expected unprefixed keys in the destination map, got %v", values)
+	}
+
+	if err := c.Delete(ctx, "1", prefix); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.BatchDelete(ctx, []string{"2"}, prefix); err != nil {
+		t.Fatal(err)
+	}
+	if len(mockKV.Data) != 0 {
+		t.Errorf("expected every key to be deleted, got %v", mockKV.Data)
+	}
+
+	// Set, BatchSet, Get, BatchGet, Delete and BatchDelete
+	want := []string{"user:1", "user:2", "user:1", "user:1", "user:2", "user:1", "user:2"}
+	if fmt.Sprint(hooked) != fmt.Sprint(want) {
+		t.Errorf("hooks received %v, want %v", hooked, want)
+	}
+}
+
+func TestClient_KeyPrefixOption(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(mockKV, Option{Encoder: json.New(), KeyPrefix: "p:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetRaw(ctx, "r", []byte(`"2"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Txn(ctx, func(tx Tx) error {
+		return tx.Set("b", "3")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SetIfAbsent(ctx, "c", "4"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"p:a", "p:r", "p:b", "p:c"} {
+		if _, ok := mockKV.Data[k]; !ok {
+			t.Errorf("expected key %s to be stored, got %v", k, mockKV.Data)
+		}
+	}
+
+	var got string
+	if _, err := c.GetWithVersion(ctx, "a", &got); err != nil || got != "1" {
+		t.Errorf("GetWithVersion() = (%q, %v), want %q", got, err, "1")
+	}
+	if _, err := c.TTL(ctx, "b"); err != nil {
+		t.Errorf("TTL() = %v, want no error", err)
+	}
+	if raw, err := c.GetRaw(ctx, "c"); err != nil || string(raw) != `"4"` {
+		t.Errorf("GetRaw() = (%q, %v), want %q", raw, err, `"4"`)
+	}
+
+	keys, err := c.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	if want := []string{"a", "b", "c", "r"}; !slices.Equal(keys, want) {
+		t.Errorf("List() = %v, want %v", keys, want)
+	}
+
+	var iterated []string
+	for entry, err := range c.Iterate(ctx, IterateOptions{Start: "b"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Decode(ctx, &got); err != nil {
+			t.Fatal(err)
+		}
+		iterated = append(iterated, entry.Key)
+	}
+	if want := []string{"b", "c", "r"}; !slices.Equal(iterated, want) {
+		t.Errorf("Iterate() = %v, want %v", iterated, want)
+	}
+
+	if err := c.DeleteRaw(ctx, "r"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := c.HasKey(ctx, "r"); err != nil || exists {
+		t.Errorf("HasKey() = (%v, %v), want the key to be deleted", exists, err)
+	}
+}
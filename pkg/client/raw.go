@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+// GetRaw retrieves the raw value stored under the specified key, as returned by the backend.
+// Unlike Get, the value is not decoded and client-side expiration envelopes are not stripped.
+// Triggers EventGet, or EventMiss if the key does not exist.
+//
+// Example:
+//
+//	raw, err := client.GetRaw(ctx, "myKey")
+func (c Client) GetRaw(ctx context.Context, key string) ([]byte, error) {
+	if key == "" {
+		return nil, errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return nil, err
+	}
+
+	raw, err := c.KV.GetRaw(ctx, key)
+	c.runReadHooks(ctx, c.opts, key, raw, err)
+
+	return raw, err
+}
+
+// SetRaw stores the raw value under the specified key without encoding it.
+// The key policy and pre-hooks apply as for Set. Triggers EventSetRaw.
+//
+// Example:
+//
+//	err := client.SetRaw(ctx, "myKey", []byte(`"myValue"`))
+func (c Client) SetRaw(ctx context.Context, key string, value []byte) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return err
+	}
+
+	key, value, err := c.runPreHooks(ctx, c.opts, EventBeforeSet, key, value)
+	if err != nil {
+		return err
+	}
+
+	if err := c.KV.SetRaw(ctx, key, value); err != nil {
+		return err
+	}
+
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, c.opts, EventSetRaw, key, value)
+
+	return nil
+}
+
+// DeleteRaw removes the specified key from the backend, ignoring the per-call options of Delete.
+// The key policy and pre-hooks apply as for Delete. Triggers EventDeleteRaw.
+//
+// Example:
+//
+//	err := client.DeleteRaw(ctx, "myKey")
+func (c Client) DeleteRaw(ctx context.Context, key string) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return err
+	}
+
+	key, _, err := c.runPreHooks(ctx, c.opts, EventBeforeDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := c.KV.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, c.opts, EventDeleteRaw, key, nil)
+
+	return nil
+}
+
+// List returns the keys starting with prefix, without the key prefix of the client.
+// Triggers EventList, with the prefix as key.
+//
+// Example:
+//
+//	keys, err := client.List(ctx, "user:")
+func (c Client) List(ctx context.Context, prefix string) ([]string, error) {
+	prefix = c.opts.key(prefix)
+
+	keys, err := c.KV.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.opts.KeyPrefix != "" {
+		for i, k := range keys {
+			keys[i] = c.opts.unprefixed(k)
+		}
+	}
+
+	// Trigger hooks after successful operation
+	c.runHooks(ctx, c.opts, EventList, prefix, nil)
+
+	return keys, nil
+}
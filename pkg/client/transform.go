@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// Transformer transforms the encoded values before they are stored, and reverts the transformation when they are read.
+// Transformers must be able to revert values stored before they were enabled, e.g. by detecting a header.
+type Transformer interface {
+	// Apply transforms the encoded value before it is stored.
+	Apply(ctx context.Context, value []byte) ([]byte, error)
+
+	// Revert restores the encoded value from the stored value.
+	Revert(ctx context.Context, value []byte) ([]byte, error)
+}
+
+// WithTransformers returns an Options that appends transformers to the value transform pipeline.
+//
+// Example:
+//
+//	client, err := kivigo.New(backend, client.WithTransformers(client.Compress(client.Gzip(), 1024)))
+func WithTransformers(transformers ...Transformer) Options {
+	return func(o Option) Option {
+		o.Transformers = append(append([]Transformer(nil), o.Transformers...), transformers...)
+		return o
+	}
+}
+
+// encode encodes the value with the encoder and applies the transformers in order.
+func (o Option) encode(ctx context.Context, value any) ([]byte, error) {
+	raw, err := o.Encoder.Encode(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.transform(ctx, raw)
+}
+
+// transform applies the transformers in order to the encoded value.
+func (o Option) transform(ctx context.Context, raw []byte) ([]byte, error) {
+	for _, t := range o.Transformers {
+		var err error
+
+		raw, err = t.Apply(ctx, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transform value: %w", err)
+		}
+	}
+
+	return raw, nil
+}
+
+// decode reverts the transformers in reverse order and decodes the value with the encoder.
+func (o Option) decode(ctx context.Context, raw []byte, value any) error {
+	raw, err := o.untransform(ctx, raw)
+	if err != nil {
+		return err
+	}
+
+	return o.Encoder.Decode(ctx, raw, value)
+}
+
+// untransform reverts the transformers in reverse order to restore the encoded value.
+func (o Option) untransform(ctx context.Context, raw []byte) ([]byte, error) {
+	for i := len(o.Transformers) - 1; i >= 0; i-- {
+		var err error
+
+		raw, err = o.Transformers[i].Revert(ctx, raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revert value transformation: %w", err)
+		}
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,277 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// ttlEnvelopeMagic prefixes values stored with a client-side expiration.
+// It is only used for backends that do not implement models.KVWithTTL.
+var ttlEnvelopeMagic = []byte{0x00, 'k', 'v', 'g', ':', 't', 't', 'l'}
+
+// ttlEnvelopeSize is the size of the envelope header: magic followed by the expiration as unix nanoseconds.
+var ttlEnvelopeSize = len(ttlEnvelopeMagic) + 8
+
+// now returns the current time used to evaluate client-side expirations.
+var now = time.Now
+
+// WithTTL returns an Options that expires the values written by Set once ttl has elapsed.
+// A zero or negative ttl disables the expiration.
+//
+// Backends implementing models.KVWithTTL handle the expiration natively. For other backends,
+// and for conditional writes and transactions which backends cannot expire natively,
+// the value is stored in an envelope carrying its expiration and is lazily reported as
+// errs.ErrNotFound once expired.
+//
+// Example:
+//
+//	err := client.Set(ctx, "session:42", token, client.WithTTL(30*time.Minute))
+func WithTTL(ttl time.Duration) Options {
+	return func(o Option) Option {
+		o.TTL = ttl
+		return o
+	}
+}
+
+// TTL returns the remaining time to live of the specified key.
+// Returns zero if the key has no expiration, or errs.ErrNotFound if the key does not exist or has expired.
+//
+// Example:
+//
+//	ttl, err := client.TTL(ctx, "session:42")
+func (c Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if key == "" {
+		return 0, errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return 0, err
+	}
+
+	if kv, ok := c.KV.(models.KVWithTTL); ok {
+		ttl, err := kv.TTL(ctx, key)
+		if (err != nil && !errors.Is(err, errs.ErrOperationNotSupported)) || ttl > 0 {
+			return ttl, err
+		}
+
+		// Values written by conditional operations and transactions carry a client-side expiration,
+		// as do the values written through a wrapper of a backend without native expiration
+	}
+
+	raw, err := c.KV.GetRaw(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	_, expiresAt, ok := unwrapTTL(raw)
+	if !ok {
+		return 0, nil
+	}
+
+	remaining := expiresAt.Sub(now())
+	if remaining <= 0 {
+		return 0, errs.ErrNotFound
+	}
+
+	return remaining, nil
+}
+
+// Persist removes the expiration of the specified key so it never expires.
+// Returns errs.ErrNotFound if the key does not exist or has expired.
+//
+// Values stored in a client-side expiration envelope are rewritten without it, which triggers EventSetRaw
+// with the rewritten value so that caches attached to the client are invalidated.
+//
+// Example:
+//
+//	err := client.Persist(ctx, "session:42")
+func (c Client) Persist(ctx context.Context, key string) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = c.opts.key(key)
+	if err := c.validateKey(c.opts, key); err != nil {
+		return err
+	}
+
+	if kv, ok := c.KV.(models.KVWithTTL); ok {
+		if err := kv.Persist(ctx, key); err != nil && !errors.Is(err, errs.ErrOperationNotSupported) {
+			return err
+		}
+
+		// Values written by conditional operations and transactions carry a client-side expiration,
+		// as do the values written through a wrapper of a backend without native expiration
+	}
+
+	raw, err := c.KV.GetRaw(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	value, expiresAt, ok := unwrapTTL(raw)
+	if !ok {
+		return nil
+	}
+
+	if !expiresAt.After(now()) {
+		return errs.ErrNotFound
+	}
+
+	if err := c.KV.SetRaw(ctx, key, value); err != nil {
+		return err
+	}
+
+	c.runHooks(ctx, c.opts, EventSetRaw, key, value)
+
+	return nil
+}
+
+// purgeExpired deletes the value of key if its client-side expiration envelope has expired, so that
+// the conditions on the absence of the key evaluated by the backend see it as missing, as Get does.
+// The value is only deleted if it is not modified concurrently, which requires models.KVWithCAS;
+// without it, expired values are left in place.
+func (c Client) purgeExpired(ctx context.Context, key string) error {
+	cas, ok := c.KV.(models.KVWithCAS)
+	if !ok {
+		return nil
+	}
+
+	raw, version, err := cas.GetRawWithVersion(ctx, key)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrOperationNotSupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, expiresAt, wrapped := unwrapTTL(raw); !wrapped || expiresAt.After(now()) {
+		return nil
+	}
+
+	// A conflict means the key was written since it was read, which the condition then reports
+	if err := cas.DeleteIfVersion(ctx, key, version); err != nil && !errors.Is(err, errs.ErrConflict) && !errors.Is(err, errs.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// setRawWithTTL stores the raw value, expiring it after ttl if ttl is positive.
+func (c Client) setRawWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return c.KV.SetRaw(ctx, key, value)
+	}
+
+	if kv, ok := c.KV.(models.KVWithTTL); ok {
+		// Wrappers such as caches implement models.KVWithTTL whether or not the backend they wrap does
+		if err := kv.SetRawWithTTL(ctx, key, value, ttl); !errors.Is(err, errs.ErrOperationNotSupported) {
+			return err
+		}
+	}
+
+	return c.KV.SetRaw(ctx, key, wrapTTL(value, now().Add(ttl)))
+}
+
+// batchSetRawWithTTL stores the raw values in a single batch, expiring them after ttl if ttl is positive.
+// Backends implementing models.KVWithTTL have no batch operation with expiration, so values are set one by one.
+func (c Client) batchSetRawWithTTL(ctx context.Context, batch models.KVWithBatch, raws map[string][]byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return batch.BatchSetRaw(ctx, raws)
+	}
+
+	if kv, ok := c.KV.(models.KVWithTTL); ok {
+		native := true
+
+		for k, v := range raws {
+			err := kv.SetRawWithTTL(ctx, k, v, ttl)
+			if errors.Is(err, errs.ErrOperationNotSupported) {
+				// Wrappers report the missing capability on the first write, before anything is written
+				native = false
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to set key %s: %w", k, err)
+			}
+		}
+
+		if native {
+			return nil
+		}
+	}
+
+	expiresAt := now().Add(ttl)
+	wrapped := make(map[string][]byte, len(raws))
+
+	for k, v := range raws {
+		wrapped[k] = wrapTTL(v, expiresAt)
+	}
+
+	return batch.BatchSetRaw(ctx, wrapped)
+}
+
+// getRaw retrieves the raw value stored under key, stripping the client-side expiration envelope if any.
+// Returns errs.ErrNotFound if the value has expired.
+func (c Client) getRaw(ctx context.Context, key string) ([]byte, error) {
+	raw, err := c.KV.GetRaw(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return stripTTL(raw)
+}
+
+// stripTTL returns the value wrapped in a client-side expiration envelope,
+// or the raw value unchanged if it is not wrapped.
+// Returns errs.ErrNotFound if the value has expired.
+func stripTTL(raw []byte) ([]byte, error) {
+	value, expiresAt, ok := unwrapTTL(raw)
+	if !ok {
+		return raw, nil
+	}
+
+	if !expiresAt.After(now()) {
+		return nil, fmt.Errorf("value expired at %s: %w", expiresAt.Format(time.RFC3339), errs.ErrNotFound)
+	}
+
+	return value, nil
+}
+
+// withTTL returns the value wrapped in a client-side expiration envelope if ttl is positive,
+// for the operations that backends cannot expire natively.
+func withTTL(value []byte, ttl time.Duration) []byte {
+	if ttl <= 0 {
+		return value
+	}
+
+	return wrapTTL(value, now().Add(ttl))
+}
+
+// wrapTTL wraps value in an envelope carrying its expiration.
+func wrapTTL(value []byte, expiresAt time.Time) []byte {
+	raw := make([]byte, ttlEnvelopeSize, ttlEnvelopeSize+len(value))
+	copy(raw, ttlEnvelopeMagic)
+	binary.BigEndian.PutUint64(raw[len(ttlEnvelopeMagic):], uint64(expiresAt.UnixNano())) //nolint:gosec
+
+	return append(raw, value...)
+}
+
+// unwrapTTL extracts the value and expiration from an envelope created by wrapTTL.
+// The last return value is false if raw is not wrapped.
+func unwrapTTL(raw []byte) ([]byte, time.Time, bool) {
+	if len(raw) < ttlEnvelopeSize || !bytes.HasPrefix(raw, ttlEnvelopeMagic) {
+		return raw, time.Time{}, false
+	}
+
+	expiresAt := int64(binary.BigEndian.Uint64(raw[len(ttlEnvelopeMagic):ttlEnvelopeSize])) //nolint:gosec
+
+	return raw[ttlEnvelopeSize:], time.Unix(0, expiresAt), true
+}
@@ -0,0 +1,266 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// basicKV hides every optional capability of the wrapped backend.
+type basicKV struct {
+	models.KV
+}
+
+func setClock(t *testing.T, current *time.Time) {
+	t.Helper()
+
+	previous := now
+	now = func() time.Time { return *current }
+
+	t.Cleanup(func() { now = previous })
+}
+
+func TestClient_SetWithTTL_Native(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}, Clock: func() time.Time { return current }}
+	c, err := New(mockKV, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "session", "token", WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Native backends store the value as is
+	if string(mockKV.Data["session"]) != `"token"` {
+		t.Errorf("expected unwrapped value, got %q", mockKV.Data["session"])
+	}
+
+	ttl, err := c.TTL(ctx, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl != time.Minute {
+		t.Errorf("TTL() = %v, want %v", ttl, time.Minute)
+	}
+
+	current = current.Add(2 * time.Minute)
+
+	var got string
+	if err := c.Get(ctx, "session", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after expiration, got %v", err)
+	}
+}
+
+func TestClient_SetWithTTL_Fallback(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	setClock(t, &current)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(basicKV{mockKV}, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "session", "token", WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "config", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok := unwrapTTL(mockKV.Data["session"]); !ok {
+		t.Fatalf("expected value to be wrapped in an envelope, got %q", mockKV.Data["session"])
+	}
+
+	var got string
+	if err := c.Get(ctx, "session", &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != "token" {
+		t.Errorf("Get() = %q, want %q", got, "token")
+	}
+
+	ttl, err := c.TTL(ctx, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl != time.Minute {
+		t.Errorf("TTL() = %v, want %v", ttl, time.Minute)
+	}
+
+	ttl, err = c.TTL(ctx, "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl != 0 {
+		t.Errorf("TTL() = %v, want 0 for a key without expiration", ttl)
+	}
+
+	current = current.Add(2 * time.Minute)
+
+	if err := c.Get(ctx, "session", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after expiration, got %v", err)
+	}
+
+	exists, err := c.HasKey(ctx, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("expected expired key to be reported as missing")
+	}
+
+	if _, err := c.TTL(ctx, "session"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound from TTL after expiration, got %v", err)
+	}
+}
+
+func TestClient_Persist_Fallback(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	setClock(t, &current)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+	c, err := New(basicKV{mockKV}, Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "session", "token", WithTTL(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Persist(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+
+	current = current.Add(time.Hour)
+
+	var got string
+	if err := c.Get(ctx, "session", &got); err != nil {
+		t.Fatalf("expected persisted key to survive, got %v", err)
+	}
+	if got != "token" {
+		t.Errorf("Get() = %q, want %q", got, "token")
+	}
+}
+
+func TestClient_DefaultTTL(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}, Clock: func() time.Time { return current }}
+	c, err := New(mockKV, Option{Encoder: json.New(), TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "default", "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A per-call option overrides the client default
+	if err := c.Set(ctx, "forever", "value", WithTTL(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	current = current.Add(2 * time.Minute)
+
+	exists, err := c.HasKey(ctx, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("expected key written with the default TTL to expire")
+	}
+
+	exists, err = c.HasKey(ctx, "forever")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("expected key written without TTL to survive")
+	}
+}
+
+func TestClient_DefaultTTL_ConditionalWrites(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	setClock(t, &current)
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}, Clock: func() time.Time { return current }}
+	c, err := New(mockKV, Option{Encoder: json.New(), TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := c.SetIfAbsent(ctx, "cas", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Txn(ctx, func(tx Tx) error {
+		return tx.Set("txn", "value")
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The backend cannot expire them natively, but the client reports their expiration
+	for _, key := range []string{"cas", "txn"} {
+		if ttl, err := c.TTL(ctx, key); err != nil || ttl != time.Minute {
+			t.Errorf("TTL(%s) = (%v, %v), want %v", key, ttl, err, time.Minute)
+		}
+	}
+
+	if err := c.Persist(ctx, "txn"); err != nil {
+		t.Fatal(err)
+	}
+
+	current = current.Add(2 * time.Minute)
+
+	var got string
+	if _, err := c.GetWithVersion(ctx, "cas", &got); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected the value written by SetIfAbsent to expire, got %v", err)
+	}
+	if err := c.Get(ctx, "txn", &got); err != nil || got != "value" {
+		t.Errorf("Get() = (%q, %v), want the persisted value", got, err)
+	}
+
+	// Expired values are missing for the conditions on absence, as for Get
+	if err := c.Txn(ctx, func(tx Tx) error {
+		if err := tx.RequireAbsent("cas"); err != nil {
+			return err
+		}
+		return tx.Set("other", "value")
+	}); err != nil {
+		t.Errorf("expected the expired key to be absent, got %v", err)
+	}
+
+	if err := c.Txn(ctx, func(tx Tx) error { return tx.Set("expiring", "value") }); err != nil {
+		t.Fatal(err)
+	}
+
+	current = current.Add(2 * time.Minute)
+
+	if _, err := c.SetIfAbsent(ctx, "expiring", "new"); err != nil {
+		t.Errorf("expected SetIfAbsent to overwrite the expired key, got %v", err)
+	}
+	if err := c.Txn(ctx, func(tx Tx) error { return tx.RequireAbsent("txn") }); !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("expected ErrConflict for a persistent key, got %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// Tx buffers the operations of a transaction started with Client.Txn.
+// Operations are only applied when the transaction function returns nil.
+type Tx interface {
+	// Get retrieves the value stored under key and decodes it into value.
+	// Values set or deleted earlier in the transaction are visible.
+	Get(key string, value any) error
+
+	// Set stores value under key when the transaction is committed.
+	Set(key string, value any) error
+
+	// Delete removes key when the transaction is committed.
+	Delete(key string) error
+
+	// RequireAbsent aborts the commit with errs.ErrConflict if key exists.
+	// A value whose client-side expiration has passed is considered missing.
+	RequireAbsent(key string) error
+}
+
+// WithCompensatingTxn returns an Options that allows Txn to run on backends that do not implement models.KVWithTxn.
+//
+// Operations are then applied one by one and, if one fails, the previous values of the written keys are restored
+// on a best-effort basis. Concurrent readers may observe partial transactions and conditions are not checked atomically.
+//
+// Example:
+//
+//	err := client.Txn(ctx, fn, client.WithCompensatingTxn())
+func WithCompensatingTxn() Options {
+	return func(o Option) Option {
+		o.CompensatingTxn = true
+		return o
+	}
+}
+
+// Txn runs fn in a transaction and commits the buffered operations atomically if fn returns nil.
+// If fn returns an error, nothing is written and the error is returned.
+// Hooks are only triggered once the transaction is committed, whereas pre-hooks are triggered when operations are
+// buffered: their errors are returned by Tx.Set and Tx.Delete, and the modified keys are the ones buffered.
+//
+// Backends implementing models.KVWithTxn commit the transaction atomically. Other backends return
+// errs.ErrOperationNotSupported unless WithCompensatingTxn is provided. With a TTL, the values committed
+// atomically are stored in a client-side expiration envelope.
+//
+// Example:
+//
+//	err := client.Txn(ctx, func(tx client.Tx) error {
+//	    if err := tx.RequireAbsent("D"); err != nil {
+//	        return err
+//	    }
+//	    if err := tx.Set("A", valueA); err != nil {
+//	        return err
+//	    }
+//	    if err := tx.Delete("B"); err != nil {
+//	        return err
+//	    }
+//	    return tx.Set("C", valueC)
+//	})
+func (c Client) Txn(ctx context.Context, fn func(tx Tx) error, opts ...Options) error {
+	if fn == nil {
+		return errs.ErrEmptyFunc
+	}
+
+	o := c.callOptions(opts)
+
+	tx := &txn{
+		ctx:    ctx,
+		client: c,
+		opts:   o,
+		writes: make(map[string]int),
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if len(tx.ops) == 0 {
+		return nil
+	}
+
+	var err error
+
+	switch kv, ok := c.KV.(models.KVWithTxn); {
+	case ok:
+		if err := tx.purgeExpired(); err != nil {
+			return err
+		}
+		err = kv.CommitTxn(ctx, tx.committed())
+
+		// Wrappers such as caches implement models.KVWithTxn whether or not the backend they wrap does
+		if errors.Is(err, errs.ErrOperationNotSupported) && o.CompensatingTxn {
+			err = c.commitWithRollback(ctx, tx.ops, o.TTL)
+		}
+	case o.CompensatingTxn:
+		err = c.commitWithRollback(ctx, tx.ops, o.TTL)
+	default:
+		return fmt.Errorf("transactions not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Trigger hooks after successful commit
+	for _, op := range tx.ops {
+		switch op.Type {
+		case models.TxnOpSet:
+			c.runHooks(ctx, o, EventSet, op.Key, op.Value)
+		case models.TxnOpDelete:
+			c.runHooks(ctx, o, EventDelete, op.Key, nil)
+		case models.TxnOpCheckAbsent:
+			// Conditions do not trigger hooks
+		}
+	}
+
+	return nil
+}
+
+// txnSnapshot holds the value of a key before a compensating transaction writes it.
+type txnSnapshot struct {
+	key    string
+	value  []byte
+	exists bool
+}
+
+// commitWithRollback applies ops one by one, restoring the previous values of written keys if one fails.
+// The values set expire after ttl if positive.
+func (c Client) commitWithRollback(ctx context.Context, ops []models.TxnOp, ttl time.Duration) error {
+	snapshots := make([]txnSnapshot, 0, len(ops))
+	seen := make(map[string]bool, len(ops))
+
+	for _, op := range ops {
+		raw, err := c.KV.GetRaw(ctx, op.Key)
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			return fmt.Errorf("failed to read key %s: %w", op.Key, err)
+		}
+
+		exists := err == nil
+		if exists {
+			// Expired values are considered missing
+			_, stripErr := stripTTL(raw)
+			exists = stripErr == nil
+		}
+
+		if op.Type == models.TxnOpCheckAbsent {
+			if exists {
+				return fmt.Errorf("key %s already exists: %w", op.Key, errs.ErrConflict)
+			}
+			continue
+		}
+
+		if !seen[op.Key] {
+			seen[op.Key] = true
+			snapshots = append(snapshots, txnSnapshot{key: op.Key, value: raw, exists: err == nil})
+		}
+	}
+
+	for _, op := range ops {
+		var err error
+
+		switch op.Type {
+		case models.TxnOpSet:
+			err = c.setRawWithTTL(ctx, op.Key, op.Value, ttl)
+		case models.TxnOpDelete:
+			if err = c.KV.Delete(ctx, op.Key); errors.Is(err, errs.ErrNotFound) {
+				err = nil
+			}
+		case models.TxnOpCheckAbsent:
+			// Already checked
+		}
+
+		if err != nil {
+			err = fmt.Errorf("failed to apply operation on key %s: %w", op.Key, err)
+			if rollbackErr := c.rollback(ctx, snapshots); rollbackErr != nil {
+				return errors.Join(err, rollbackErr)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollback restores the given snapshots in reverse order.
+func (c Client) rollback(ctx context.Context, snapshots []txnSnapshot) error {
+	var errList []error
+
+	for _, s := range slices.Backward(snapshots) {
+		var err error
+		if s.exists {
+			err = c.KV.SetRaw(ctx, s.key, s.value)
+		} else if err = c.KV.Delete(ctx, s.key); errors.Is(err, errs.ErrNotFound) {
+			err = nil
+		}
+
+		if err != nil {
+			errList = append(errList, fmt.Errorf("failed to restore key %s: %w", s.key, err))
+		}
+	}
+
+	if len(errList) > 0 {
+		return fmt.Errorf("rollback failed: %w", errors.Join(errList...))
+	}
+
+	return nil
+}
+
+// txn implements Tx by buffering operations until commit.
+type txn struct {
+	ctx    context.Context //nolint:containedctx // a transaction is bound to the context of Txn
+	client Client
+	opts   Option
+	ops    []models.TxnOp
+	writes map[string]int // index in ops of the last write of each key
+}
+
+func (t *txn) Get(key string, value any) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = t.opts.key(key)
+	if err := t.client.validateKey(t.opts, key); err != nil {
+		return err
+	}
+
+	if i, ok := t.writes[key]; ok {
+		if t.ops[i].Type == models.TxnOpDelete {
+			return errs.ErrNotFound
+		}
+		return t.opts.decode(t.ctx, t.ops[i].Value, value)
+	}
+
+	raw, err := t.client.getRaw(t.ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return t.opts.decode(t.ctx, raw, value)
+}
+
+func (t *txn) Set(key string, value any) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = t.opts.key(key)
+	if err := t.client.validateKey(t.opts, key); err != nil {
+		return err
+	}
+
+	raw, err := t.opts.encode(t.ctx, value)
+	if err != nil {
+		return err
+	}
+
+	key, raw, err = t.client.runPreHooks(t.ctx, t.opts, EventBeforeSet, key, raw)
+	if err != nil {
+		return err
+	}
+
+	t.writes[key] = len(t.ops)
+	t.ops = append(t.ops, models.TxnOp{Type: models.TxnOpSet, Key: key, Value: raw})
+
+	return nil
+}
+
+func (t *txn) Delete(key string) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = t.opts.key(key)
+	if err := t.client.validateKey(t.opts, key); err != nil {
+		return err
+	}
+
+	key, _, err := t.client.runPreHooks(t.ctx, t.opts, EventBeforeDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	t.writes[key] = len(t.ops)
+	t.ops = append(t.ops, models.TxnOp{Type: models.TxnOpDelete, Key: key})
+
+	return nil
+}
+
+func (t *txn) RequireAbsent(key string) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	key = t.opts.key(key)
+	if err := t.client.validateKey(t.opts, key); err != nil {
+		return err
+	}
+
+	t.ops = append(t.ops, models.TxnOp{Type: models.TxnOpCheckAbsent, Key: key})
+
+	return nil
+}
+
+// purgeExpired deletes the expired values of the keys required to be absent, which the backend would
+// otherwise consider present.
+func (t *txn) purgeExpired() error {
+	for _, op := range t.ops {
+		if op.Type != models.TxnOpCheckAbsent {
+			continue
+		}
+
+		if err := t.client.purgeExpired(t.ctx, op.Key); err != nil {
+			return fmt.Errorf("failed to read key %s: %w", op.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// committed returns the buffered operations as committed atomically, with the values set wrapped in
+// a client-side expiration envelope if the transaction has a TTL.
+func (t *txn) committed() []models.TxnOp {
+	if t.opts.TTL <= 0 {
+		return t.ops
+	}
+
+	ops := slices.Clone(t.ops)
+	for i, op := range ops {
+		if op.Type == models.TxnOpSet {
+			ops[i].Value = withTTL(op.Value, t.opts.TTL)
+		}
+	}
+
+	return ops
+}
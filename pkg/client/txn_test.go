@@ -0,0 +1,212 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// plainKV hides every optional capability of the wrapped backend
+// and fails writes to failKey.
+type plainKV struct {
+	models.KV
+	failKey string
+}
+
+func (p plainKV) SetRaw(ctx context.Context, key string, value []byte) error {
+	if key == p.failKey {
+		return errors.New("write failed")
+	}
+
+	return p.KV.SetRaw(ctx, key, value)
+}
+
+func Test_Txn(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{
+		"B": []byte(`"b"`),
+	}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+
+	_, _, unregister := c.RegisterHook(func(_ context.Context, evt client.EventType, key string, _ []byte) error {
+		events = append(events, string(evt)+" "+key)
+		return nil
+	}, client.HookOptions{})
+	defer unregister()
+
+	ctx := context.Background()
+
+	err = c.Txn(ctx, func(tx client.Tx) error {
+		if err := tx.RequireAbsent("D"); err != nil {
+			return err
+		}
+		if err := tx.Set("A", "a"); err != nil {
+			return err
+		}
+		if err := tx.Delete("B"); err != nil {
+			return err
+		}
+
+		// Buffered operations are visible within the transaction
+		var got string
+		if err := tx.Get("A", &got); err != nil || got != "a" {
+			t.Errorf("tx.Get(A) = (%q, %v), want (%q, nil)", got, err, "a")
+		}
+		if err := tx.Get("B", &got); !errors.Is(err, errs.ErrNotFound) {
+			t.Errorf("expected ErrNotFound for a deleted key, got %v", err)
+		}
+
+		// Nothing is written before commit
+		if len(events) != 0 || mockKV.Data["A"] != nil {
+			t.Error("expected operations to be buffered until commit")
+		}
+
+		return tx.Set("C", "c")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	if err := c.Get(ctx, "C", &got); err != nil || got != "c" {
+		t.Errorf("Get(C) = (%q, %v), want (%q, nil)", got, err, "c")
+	}
+	if _, ok := mockKV.Data["B"]; ok {
+		t.Error("expected B to be deleted")
+	}
+
+	expectedEvents := []string{"SET A", "DELETE B", "SET C", "GET C"}
+	if len(events) != len(expectedEvents) {
+		t.Fatalf("events = %v, want %v", events, expectedEvents)
+	}
+	for i := range expectedEvents {
+		if events[i] != expectedEvents[i] {
+			t.Errorf("events[%d] = %q, want %q", i, events[i], expectedEvents[i])
+		}
+	}
+}
+
+func Test_Txn_Conflict(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{
+		"D": []byte(`"d"`),
+	}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Txn(context.Background(), func(tx client.Tx) error {
+		if err := tx.Set("A", "a"); err != nil {
+			return err
+		}
+		return tx.RequireAbsent("D")
+	})
+	if !errors.Is(err, errs.ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+
+	if _, ok := mockKV.Data["A"]; ok {
+		t.Error("expected no write when a condition fails")
+	}
+}
+
+func Test_Txn_Abort(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	abortErr := errors.New("abort")
+
+	err = c.Txn(context.Background(), func(tx client.Tx) error {
+		if err := tx.Set("A", "a"); err != nil {
+			return err
+		}
+		return abortErr
+	})
+	if !errors.Is(err, abortErr) {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+
+	if len(mockKV.Data) != 0 {
+		t.Errorf("expected no write on abort, got %v", mockKV.Data)
+	}
+
+	if err := c.Txn(context.Background(), nil); !errors.Is(err, errs.ErrEmptyFunc) {
+		t.Errorf("expected ErrEmptyFunc, got %v", err)
+	}
+}
+
+func Test_Txn_Compensating(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{
+		"A": []byte(`"old"`),
+	}}
+
+	c, err := client.New(plainKV{KV: mockKV, failKey: "C"}, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	fn := func(tx client.Tx) error {
+		if err := tx.Set("A", "new"); err != nil {
+			return err
+		}
+		if err := tx.Set("B", "b"); err != nil {
+			return err
+		}
+		return tx.Set("C", "c")
+	}
+
+	if err := c.Txn(ctx, fn); !errors.Is(err, errs.ErrOperationNotSupported) {
+		t.Fatalf("expected ErrOperationNotSupported without compensating mode, got %v", err)
+	}
+
+	if err := c.Txn(ctx, fn, client.WithCompensatingTxn()); err == nil {
+		t.Fatal("expected the failing write to abort the transaction")
+	}
+
+	// Previous writes are rolled back
+	if string(mockKV.Data["A"]) != `"old"` {
+		t.Errorf("expected A to be restored, got %q", mockKV.Data["A"])
+	}
+	if _, ok := mockKV.Data["B"]; ok {
+		t.Error("expected B to be removed")
+	}
+
+	err = c.Txn(ctx, func(tx client.Tx) error {
+		if err := tx.RequireAbsent("A"); err != nil {
+			return err
+		}
+		return tx.Set("B", "b")
+	}, client.WithCompensatingTxn())
+	if !errors.Is(err, errs.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+
+	err = c.Txn(ctx, func(tx client.Tx) error {
+		return tx.Set("B", "b")
+	}, client.WithCompensatingTxn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(mockKV.Data["B"]) != `"b"` {
+		t.Errorf("expected B to be written, got %q", mockKV.Data["B"])
+	}
+}
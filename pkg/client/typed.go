@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+type (
+	// Typed is a view of a Client whose values are all of type T.
+	// Type mismatches are caught at compile time, and batch reads do not rely on reflection.
+	Typed[T any] struct {
+		client Client
+	}
+
+	// TypedEntry is a key and its decoded value yielded by Typed.Iterate.
+	TypedEntry[T any] struct {
+		Key   string
+		Value T
+	}
+)
+
+// NewTyped returns a view of the client whose values are all of type T.
+//
+// Example:
+//
+//	users := client.NewTyped[User](c)
+//	user, err := users.Get(ctx, "user:42")
+func NewTyped[T any](c Client) Typed[T] {
+	return Typed[T]{client: c}
+}
+
+// Client returns the underlying client.
+func (t Typed[T]) Client() Client {
+	return t.client
+}
+
+// Get retrieves and decodes the value stored under the specified key.
+// Returns errs.ErrNotFound if the key does not exist.
+func (t Typed[T]) Get(ctx context.Context, key string, opts ...Options) (T, error) {
+	var value T
+
+	if err := t.client.Get(ctx, key, &value, opts...); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// Set stores the given value under the specified key.
+func (t Typed[T]) Set(ctx context.Context, key string, value T, opts ...Options) error {
+	return t.client.Set(ctx, key, value, opts...)
+}
+
+// Delete removes the value associated with the specified key.
+func (t Typed[T]) Delete(ctx context.Context, key string, opts ...Options) error {
+	return t.client.Delete(ctx, key, opts...)
+}
+
+// GetOrDefault retrieves the value stored under the specified key, or returns def if the key does not exist.
+//
+// Example:
+//
+//	limit, err := limits.GetOrDefault(ctx, "rate:api", 100)
+func (t Typed[T]) GetOrDefault(ctx context.Context, key string, def T, opts ...Options) (T, error) {
+	value, err := t.Get(ctx, key, opts...)
+	if errors.Is(err, errs.ErrNotFound) {
+		return def, nil
+	}
+
+	return value, err
+}
+
+// GetOrSet retrieves the value stored under the specified key. If the key does not exist,
+// the value is computed with fn, stored and returned.
+//
+// Concurrent callers may compute and store the value more than once; use Update for atomic updates.
+//
+// Example:
+//
+//	profile, err := profiles.GetOrSet(ctx, "profile:42", func() (Profile, error) {
+//	    return loadProfile(ctx, 42)
+//	})
+func (t Typed[T]) GetOrSet(ctx context.Context, key string, fn func() (T, error), opts ...Options) (T, error) {
+	var zero T
+
+	if fn == nil {
+		return zero, errs.ErrEmptyFunc
+	}
+
+	value, err := t.Get(ctx, key, opts...)
+	if !errors.Is(err, errs.ErrNotFound) {
+		return value, err
+	}
+
+	value, err = fn()
+	if err != nil {
+		return zero, err
+	}
+
+	if err := t.Set(ctx, key, value, opts...); err != nil {
+		return zero, err
+	}
+
+	return value, nil
+}
+
+// BatchGet retrieves and decodes the values of multiple keys in a single batch operation.
+// Keys that do not exist are not set in the returned map.
+func (t Typed[T]) BatchGet(ctx context.Context, keys []string, opts ...Options) (map[string]T, error) {
+	o := t.client.callOptions(opts)
+
+	opCtx, cancel := o.context(ctx)
+	defer cancel()
+
+	raws, err := t.client.batchGetRaw(ctx, opCtx, o, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]T, len(raws))
+
+	for k, raw := range raws {
+		var value T
+		if err := o.decode(opCtx, raw, &value); err != nil {
+			return nil, fmt.Errorf("failed to decode value for key %s: %w", k, err)
+		}
+		values[k] = value
+	}
+
+	return values, nil
+}
+
+// BatchSet stores multiple values in a single batch operation.
+func (t Typed[T]) BatchSet(ctx context.Context, values map[string]T, opts ...Options) error {
+	kv := make(map[string]any, len(values))
+	for k, v := range values {
+		kv[k] = v
+	}
+
+	return t.client.BatchSet(ctx, kv, opts...)
+}
+
+// BatchDelete removes multiple values in a single batch operation.
+func (t Typed[T]) BatchDelete(ctx context.Context, keys []string, opts ...Options) error {
+	return t.client.BatchDelete(ctx, keys, opts...)
+}
+
+// Iterate returns an iterator over the keys matching the given options and their decoded values,
+// in lexicographic order. If an error occurs, it is yielded with a zero TypedEntry and the iteration stops.
+//
+// Example:
+//
+//	for entry, err := range users.Iterate(ctx, client.IterateOptions{Prefix: "user:"}) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(entry.Key, entry.Value.Name)
+//	}
+func (t Typed[T]) Iterate(ctx context.Context, opts IterateOptions) iter.Seq2[TypedEntry[T], error] {
+	return func(yield func(TypedEntry[T], error) bool) {
+		for entry, err := range t.client.Iterate(ctx, opts) {
+			if err != nil {
+				yield(TypedEntry[T]{}, err)
+				return
+			}
+
+			var value T
+			if err := entry.Decode(ctx, &value); err != nil {
+				if errors.Is(err, errs.ErrNotFound) {
+					// Deleted or expired since it was listed
+					continue
+				}
+
+				yield(TypedEntry[T]{}, fmt.Errorf("failed to decode value for key %s: %w", entry.Key, err))
+				return
+			}
+
+			if !yield(TypedEntry[T]{Key: entry.Key, Value: value}, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func newTypedUsers(t *testing.T) (client.Typed[user], *mock.MockKV) {
+	t.Helper()
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client.NewTyped[user](c), mockKV
+}
+
+func Test_Typed_GetSet(t *testing.T) {
+	users, _ := newTypedUsers(t)
+	ctx := context.Background()
+
+	alice := user{Name: "alice", Age: 30}
+
+	if err := users.Set(ctx, "user:1", alice); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := users.Get(ctx, "user:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != alice {
+		t.Errorf("Get() = %+v, want %+v", got, alice)
+	}
+
+	if _, err := users.Get(ctx, "user:2"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	def := user{Name: "guest"}
+
+	got, err = users.GetOrDefault(ctx, "user:2", def)
+	if err != nil || got != def {
+		t.Errorf("GetOrDefault() = (%+v, %v), want %+v", got, err, def)
+	}
+
+	if err := users.Delete(ctx, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Get(ctx, "user:1"); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func Test_Typed_GetOrSet(t *testing.T) {
+	users, _ := newTypedUsers(t)
+	ctx := context.Background()
+
+	calls := 0
+	load := func() (user, error) {
+		calls++
+		return user{Name: "bob"}, nil
+	}
+
+	for range 2 {
+		got, err := users.GetOrSet(ctx, "user:1", load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != "bob" {
+			t.Errorf("GetOrSet() = %+v", got)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the value to be computed once, got %d calls", calls)
+	}
+
+	failure := errors.New("load failed")
+	if _, err := users.GetOrSet(ctx, "user:2", func() (user, error) { return user{}, failure }); !errors.Is(err, failure) {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+
+	if _, err := users.GetOrSet(ctx, "user:2", nil); !errors.Is(err, errs.ErrEmptyFunc) {
+		t.Errorf("expected ErrEmptyFunc, got %v", err)
+	}
+}
+
+func Test_Typed_Batch(t *testing.T) {
+	users, mockKV := newTypedUsers(t)
+	ctx := context.Background()
+
+	values := map[string]user{
+		"user:1": {Name: "alice"},
+		"user:2": {Name: "bob"},
+	}
+
+	if err := users.BatchSet(ctx, values); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := users.BatchGet(ctx, []string{"user:1", "user:2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["user:1"] != values["user:1"] || got["user:2"] != values["user:2"] {
+		t.Errorf("BatchGet() = %+v, want %+v", got, values)
+	}
+
+	mockKV.Data["user:3"] = []byte("not json")
+
+	if _, err := users.BatchGet(ctx, []string{"user:3"}); err == nil {
+		t.Error("expected a decoding error")
+	}
+
+	if err := users.BatchDelete(ctx, []string{"user:1", "user:2"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mockKV.Data["user:1"]; ok {
+		t.Error("expected user:1 to be deleted")
+	}
+}
+
+func Test_Typed_Iterate(t *testing.T) {
+	users, _ := newTypedUsers(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if err := users.Set(ctx, "user:"+name, user{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var names []string
+
+	for entry, err := range users.Iterate(ctx, client.IterateOptions{Prefix: "user:"}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Key != "user:"+entry.Value.Name {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+		names = append(names, entry.Value.Name)
+	}
+
+	if len(names) != 3 || names[0] != "alice" || names[2] != "carol" {
+		t.Errorf("Iterate() yielded %v", names)
+	}
+}
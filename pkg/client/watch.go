@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
+)
+
+// WatchEvent is a change reported by Client.Watch.
+type WatchEvent struct {
+	// Type is EventSet when the key was created or updated, EventDelete when it was deleted.
+	Type EventType
+	Key  string
+
+	// OldValue is the raw value before the change, or nil if unknown or if the key did not exist.
+	OldValue []byte
+
+	// NewValue is the raw value after the change, or nil for deletions.
+	NewValue []byte
+
+	// Revision is a backend-specific, monotonically increasing identifier of the change.
+	Revision uint64
+
+	// Err is set if the transformers of the client failed to revert the values, which are then left as stored.
+	Err error
+}
+
+// watchEventKey is the context key under which WatchHooks stores the event being dispatched.
+type watchEventKey struct{}
+
+// Watch reports the changes made to keys starting with prefix, including writes made by other clients or processes.
+// The keys are reported without the key prefix of the client, and the values as encoded,
+// with the transformers of the client reverted.
+// The returned channel is closed once ctx is done.
+// Returns errs.ErrOperationNotSupported if the backend does not implement models.KVWithWatch.
+//
+// Example:
+//
+//	events, err := client.Watch(ctx, "config:")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for evt := range events {
+//	    fmt.Printf("%s %s (revision %d)\n", evt.Type, evt.Key, evt.Revision)
+//	}
+func (c Client) Watch(ctx context.Context, prefix string) (<-chan WatchEvent, error) {
+	watcher, ok := c.KV.(models.KVWithWatch)
+	if !ok {
+		return nil, fmt.Errorf("watch not supported by backend: %w", errs.ErrOperationNotSupported)
+	}
+
+	raws, err := watcher.WatchRaw(ctx, c.opts.key(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan WatchEvent)
+
+	go func() {
+		defer close(ch)
+
+		for raw := range raws {
+			evt := c.watchEvent(ctx, raw)
+
+			select {
+			case ch <- evt:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// WatchHooks dispatches the changes reported by Watch to the registered hooks until ctx is done,
+// so that hooks also observe writes made by other clients or processes.
+//
+// The hooks of the outer clients of a namespaced view are triggered as well, with the keys as seen by those clients.
+// Writes made through this client are reported twice: once by the client itself and once by the watch.
+// Use WatchEventFromContext within a hook to tell them apart.
+//
+// Example:
+//
+//	if err := client.WatchHooks(ctx, "config:"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (c Client) WatchHooks(ctx context.Context, prefix string) error {
+	if c.hooks == nil {
+		return errs.ErrClientNotInitialized
+	}
+
+	events, err := c.Watch(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for evt := range events {
+			c.runHooks(context.WithValue(ctx, watchEventKey{}, evt), c.opts, evt.Type, c.opts.key(evt.Key), evt.NewValue)
+		}
+	}()
+
+	return nil
+}
+
+// WatchEventFromContext returns the watch event being dispatched to a hook by WatchHooks.
+// The second return value is false if the hook was triggered by an operation of the client itself.
+func WatchEventFromContext(ctx context.Context) (WatchEvent, bool) {
+	evt, ok := ctx.Value(watchEventKey{}).(WatchEvent)
+	return evt, ok
+}
+
+// watchEvent converts an event reported by the backend, reverting the transformers of the client on its values.
+func (c Client) watchEvent(ctx context.Context, raw models.WatchEvent) WatchEvent {
+	evt := WatchEvent{
+		Type:     EventSet,
+		Key:      c.opts.unprefixed(raw.Key),
+		OldValue: stripEnvelope(raw.OldValue),
+		NewValue: stripEnvelope(raw.NewValue),
+		Revision: raw.Revision,
+	}
+
+	if raw.Type == models.WatchEventDelete {
+		evt.Type = EventDelete
+	}
+
+	if len(c.opts.Transformers) == 0 {
+		return evt
+	}
+
+	oldValue, oldErr := c.untransformWatched(ctx, evt.OldValue)
+	newValue, newErr := c.untransformWatched(ctx, evt.NewValue)
+	if err := errors.Join(oldErr, newErr); err != nil {
+		evt.Err = err
+		return evt
+	}
+
+	evt.OldValue, evt.NewValue = oldValue, newValue
+
+	return evt
+}
+
+// untransformWatched reverts the transformers of the client on a value reported by Watch, if any.
+func (c Client) untransformWatched(ctx context.Context, value []byte) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	return c.opts.untransform(ctx, value)
+}
+
+// stripEnvelope returns the raw value without its client-side expiration envelope, whether it has expired or not.
+func stripEnvelope(raw []byte) []byte {
+	value, _, _ := unwrapTTL(raw)
+	return value
+}
@@ -0,0 +1,230 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/mock"
+)
+
+func Test_Watch(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	watcher, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Another client sharing the same backend
+	writer, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := watcher.Watch(ctx, "config:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Set(ctx, "config:a", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Set(ctx, "other", "ignored"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Set(ctx, "config:a", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Delete(ctx, "config:a"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []client.WatchEvent{
+		{Type: client.EventSet, Key: "config:a", NewValue: []byte(`"v1"`)},
+		{Type: client.EventSet, Key: "config:a", OldValue: []byte(`"v1"`), NewValue: []byte(`"v2"`)},
+		{Type: client.EventDelete, Key: "config:a", OldValue: []byte(`"v2"`)},
+	}
+
+	var lastRevision uint64
+
+	for i, w := range want {
+		select {
+		case evt := <-events:
+			if evt.Type != w.Type || evt.Key != w.Key || string(evt.OldValue) != string(w.OldValue) || string(evt.NewValue) != string(w.NewValue) {
+				t.Errorf("event %d = %+v, want %+v", i, evt, w)
+			}
+			if evt.Revision <= lastRevision {
+				t.Errorf("event %d revision %d is not greater than %d", i, evt.Revision, lastRevision)
+			}
+			lastRevision = evt.Revision
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected no more events")
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the channel to be closed once the context is done")
+	}
+}
+
+func Test_Watch_NotSupported(t *testing.T) {
+	c, err := client.New(&dummyKV{}, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Watch(context.Background(), ""); !errors.Is(err, errs.ErrOperationNotSupported) {
+		t.Errorf("expected ErrOperationNotSupported, got %v", err)
+	}
+
+	if err := c.WatchHooks(context.Background(), ""); !errors.Is(err, errs.ErrOperationNotSupported) {
+		t.Errorf("expected ErrOperationNotSupported, got %v", err)
+	}
+}
+
+func Test_WatchHooks(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type call struct {
+		evt      client.EventType
+		key      string
+		external bool
+	}
+
+	calls := make(chan call, 10)
+
+	_, _, unregister := c.RegisterHook(func(ctx context.Context, evt client.EventType, key string, _ []byte) error {
+		_, external := client.WatchEventFromContext(ctx)
+		calls <- call{evt: evt, key: key, external: external}
+		return nil
+	}, client.HookOptions{})
+	defer unregister()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := c.WatchHooks(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// A write made by another process, bypassing the client
+	if err := mockKV.SetRaw(ctx, "external", []byte(`"value"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-calls:
+		if got != (call{evt: client.EventSet, key: "external", external: true}) {
+			t.Errorf("hook call = %+v, want an external SET on key external", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the hook to be called")
+	}
+}
+
+func Test_Watch_Transformers(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.WithTransformers(client.Compress(client.Gzip(), 0))(client.Option{Encoder: json.New()}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := c.Watch(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Set(ctx, "a", "compressed"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case evt := <-events:
+		if evt.Err != nil || string(evt.NewValue) != `"compressed"` {
+			t.Errorf("expected the decompressed value, got %q (%v)", evt.NewValue, evt.Err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+}
+
+func Test_Watch_Namespace(t *testing.T) {
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := make(chan string, 10)
+
+	_, _, unregister := c.RegisterHook(func(_ context.Context, _ client.EventType, key string, _ []byte) error {
+		keys <- key
+		return nil
+	}, client.HookOptions{})
+	defer unregister()
+
+	billing := c.WithNamespace("billing")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := billing.Watch(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := billing.WatchHooks(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mockKV.SetRaw(ctx, "other", []byte(`"ignored"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockKV.SetRaw(ctx, "billing:a", []byte(`"value"`)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case evt := <-events:
+		if evt.Key != "a" {
+			t.Errorf("expected key a without the namespace, got %q", evt.Key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+
+	// Hooks of the outer client see the key with the namespace
+	select {
+	case key := <-keys:
+		if key != "billing:a" {
+			t.Errorf("expected key billing:a, got %q", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the outer hook")
+	}
+}
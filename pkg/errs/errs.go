@@ -11,6 +11,14 @@ var (
 	ErrClientNotInitialized  = errors.New("client is not initialized")
 	ErrEmptyBatch            = errors.New("empty batch provided")
 	ErrEmptyEncoder          = errors.New("encoder is nil")
+	ErrConflict              = errors.New("version conflict")
+	ErrUnknownKeyID          = errors.New("unknown encryption key ID")
+	ErrMissingVariable       = errors.New("template variable is missing")
+	ErrInvalidKey            = errors.New("key is invalid")
+	ErrHookRejected          = errors.New("operation rejected by hook")
+	ErrHookQueueFull         = errors.New("hook queue is full")
+	ErrValueTooLarge         = errors.New("value is too large")
+	ErrNotEncrypted          = errors.New("value is not encrypted")
 )
 
 var ErrHealthCheckFailed = func(err error) error {
@@ -16,6 +16,7 @@ func TestBackendErrors(t *testing.T) {
 		{"ErrEmptyKey", ErrEmptyKey, "key is empty"},
 		{"ErrNotFound", ErrNotFound, "key not found"},
 		{"ErrEmptyPrefix", ErrEmptyPrefix, "prefix is empty"},
+		{"ErrInvalidKey", ErrInvalidKey, "key is invalid"},
 	}
 
 	for _, tt := range tests {
@@ -36,6 +37,13 @@ func TestKVErrors(t *testing.T) {
 		{"ErrClientNotInitialized", ErrClientNotInitialized, "client is not initialized"},
 		{"ErrEmptyBatch", ErrEmptyBatch, "empty batch provided"},
 		{"ErrNotFound", ErrNotFound, "key not found"},
+		{"ErrConflict", ErrConflict, "version conflict"},
+		{"ErrUnknownKeyID", ErrUnknownKeyID, "unknown encryption key ID"},
+		{"ErrMissingVariable", ErrMissingVariable, "template variable is missing"},
+		{"ErrHookRejected", ErrHookRejected, "operation rejected by hook"},
+		{"ErrHookQueueFull", ErrHookQueueFull, "hook queue is full"},
+		{"ErrValueTooLarge", ErrValueTooLarge, "value is too large"},
+		{"ErrNotEncrypted", ErrNotEncrypted, "value is not encrypted"},
 	}
 
 	for _, tt := range tests {
@@ -95,6 +103,7 @@ func TestErrorsAreDistinct(t *testing.T) {
 		ErrEmptyPrefix,
 		ErrClientNotInitialized,
 		ErrEmptyBatch,
+		ErrConflict,
 	}
 
 	for i, err1 := range allErrors {
@@ -0,0 +1,200 @@
+package key
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+type (
+	// program is the compiled form of a template.
+	program struct {
+		// src is the template the program was compiled from.
+		src   string
+		nodes []node
+		// pattern matches the keys built from the template, capturing the value of each placeholder in order.
+		// It is nil if the template references other templates, as their content is only known at runtime.
+		pattern *regexp.Regexp
+		hasRefs bool
+		// size is an estimate of the length of the keys, used to preallocate them.
+		size int
+	}
+
+	// node is a literal part or a placeholder of a template.
+	node struct {
+		literal string
+
+		// References to other templates only
+		ref string
+
+		// Placeholders only
+		isVar      bool
+		name       string
+		nested     bool // the name is a dotted path
+		transforms []string
+		calls      []funcCall
+		hasDef     bool // a default transform is applied
+	}
+
+	// funcCall is a transform call of a placeholder, with its function resolved at compile time.
+	funcCall struct {
+		name string
+		args []string
+		fn   TransformFunc
+	}
+)
+
+// compile parses the template into a program, resolving the transform functions from funcs.
+// Unknown functions are left unresolved and reported when the placeholder is evaluated.
+func compile(tmpl string, funcs map[string]TransformFunc) (*program, error) {
+	p := &program{src: tmpl}
+
+	for _, seg := range segments(tmpl) {
+		if seg.token == "" {
+			p.nodes = append(p.nodes, node{literal: seg.literal})
+			p.size += len(seg.literal)
+			continue
+		}
+
+		if ref, ok := strings.CutPrefix(seg.token, refMarker); ok {
+			if ref == "" || strings.Contains(ref, "|") {
+				return nil, fmt.Errorf("invalid template reference {%s}: expected {%sname}", seg.token, refMarker)
+			}
+			p.nodes = append(p.nodes, node{ref: ref})
+			p.hasRefs = true
+			continue
+		}
+
+		parts := strings.Split(seg.token, "|")
+		n := node{
+			isVar:      true,
+			name:       parts[0],
+			nested:     strings.Contains(parts[0], "."),
+			transforms: parts[1:],
+		}
+		for _, fncall := range parts[1:] {
+			name, args := parseFuncCall(fncall)
+			n.calls = append(n.calls, funcCall{name: name, args: args, fn: funcs[name]})
+			n.hasDef = n.hasDef || name == "default"
+		}
+
+		p.nodes = append(p.nodes, n)
+		p.size += 16
+	}
+
+	if !p.hasRefs {
+		pattern, err := compilePattern(tmpl)
+		if err != nil {
+			return nil, err
+		}
+		p.pattern = pattern
+	}
+
+	return p, nil
+}
+
+// resolve returns a copy of the program with the transform calls named name set to fn.
+// Programs are never modified once compiled, so that they can be evaluated concurrently.
+func (p *program) resolve(name string, fn TransformFunc) *program {
+	resolved := *p
+	resolved.nodes = slices.Clone(p.nodes)
+
+	for i := range resolved.nodes {
+		n := &resolved.nodes[i]
+		if !slices.ContainsFunc(n.calls, func(c funcCall) bool { return c.name == name }) {
+			continue
+		}
+
+		n.calls = slices.Clone(n.calls)
+		for j := range n.calls {
+			if n.calls[j].name == name {
+				n.calls[j].fn = fn
+			}
+		}
+	}
+
+	return &resolved
+}
+
+// program returns the compiled template.
+func (t *TemplateKeyBuilder) program() (*program, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	// The template is compiled by Template, unless the builder was created or modified by hand
+	if t.prog != nil && t.prog.src == t.Template {
+		return t.prog, nil
+	}
+
+	return compile(t.Template, t.funcs)
+}
+
+// eval evaluates the placeholder n with the variables of data.
+func (t *TemplateKeyBuilder) eval(n *node, data any, root reflect.Value) (string, error) {
+	sval, err := lookupString(data, root, n.name, n.nested)
+	if err != nil {
+		return "", err
+	}
+
+	if t.strict && sval == "" && !n.hasDef {
+		return "", fmt.Errorf("%w: %q", errs.ErrMissingVariable, n.name)
+	}
+
+	for _, c := range n.calls {
+		if c.fn == nil {
+			return "", fmt.Errorf("unknown transform function: %s", c.name)
+		}
+		if sval, err = c.fn(sval, c.args...); err != nil {
+			return "", err
+		}
+	}
+
+	return sval, nil
+}
+
+// lookupString returns the formatted value of the variable name, or an empty string if it is not set.
+// Variables of plain maps are looked up without reflection.
+func lookupString(data any, root reflect.Value, name string, nested bool) (string, error) {
+	if m, ok := data.(map[string]interface{}); ok {
+		if v, ok := m[name]; ok || !nested {
+			return formatAny(v), nil
+		}
+		root = reflect.ValueOf(m)
+	}
+
+	val, ok, err := lookupVar(root, name)
+	if err != nil || !ok {
+		return "", err
+	}
+
+	sval, _ := formatValue(val)
+
+	return sval, nil
+}
+
+// formatAny formats the value of a variable, avoiding fmt for the common types.
+func formatAny(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	sval, _ := formatValue(reflect.ValueOf(v))
+
+	return sval
+}
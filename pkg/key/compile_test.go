@@ -0,0 +1,201 @@
+package key
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// legacyBuild is the previous implementation of Build, which scans and parses the template on every call.
+// It is kept as a baseline for the benchmarks.
+func legacyBuild(t *TemplateKeyBuilder, data any) (string, error) { //nolint:cyclop
+	vars := make(map[string]interface{})
+	switch v := data.(type) {
+	case KeyVars:
+		vars = v.KeyVars()
+	case map[string]interface{}:
+		vars = v
+	default:
+		rv := reflect.ValueOf(data)
+		if rv.Kind() == reflect.Ptr {
+			rv = rv.Elem()
+		}
+		if rv.Kind() == reflect.Struct {
+			rt := rv.Type()
+			for i := 0; i < rt.NumField(); i++ {
+				f := rt.Field(i)
+				if f.PkgPath == "" {
+					vars[f.Name] = rv.Field(i).Interface()
+				}
+			}
+		}
+	}
+
+	tmpl := t.Template
+	result := ""
+	start := 0
+	for {
+		op := strings.Index(tmpl[start:], "{")
+		if op == -1 {
+			result += tmpl[start:]
+			break
+		}
+		op += start
+		result += tmpl[start:op]
+		cl := strings.Index(tmpl[op:], "}")
+		if cl == -1 {
+			result += tmpl[op:]
+			break
+		}
+		cl += op
+		parts := strings.Split(tmpl[op+1:cl], "|")
+		sval := ""
+		if val, ok := vars[parts[0]]; ok {
+			sval = fmt.Sprintf("%v", val)
+		}
+		for _, fncall := range parts[1:] {
+			fn, args := parseFuncCall(fncall)
+			f, ok := t.funcs[fn]
+			if !ok {
+				return "", fmt.Errorf("unknown transform function: %s", fn)
+			}
+			var err error
+			if sval, err = f(sval, args...); err != nil {
+				return "", err
+			}
+		}
+		result += sval
+		start = cl + 1
+	}
+	return result, nil
+}
+
+type benchUser struct {
+	ID     int
+	Region string
+	Kind   string
+}
+
+const benchTemplate = "user:{ID}:region:{Region|upper}:kind:{Kind|default('profile')}"
+
+var benchInputs = map[string]any{
+	"map":    map[string]interface{}{"ID": 42, "Region": "eu", "Kind": ""},
+	"struct": benchUser{ID: 42, Region: "eu"},
+}
+
+func TestTemplateKeyBuilder_BuildMatchesLegacy(t *testing.T) {
+	tpl, err := Template(benchTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, data := range benchInputs {
+		want, err := legacyBuild(tpl, data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, err := tpl.Build(context.Background(), data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+
+		compiled := testing.AllocsPerRun(100, func() { _, _ = tpl.Build(context.Background(), data) })
+		legacy := testing.AllocsPerRun(100, func() { _, _ = legacyBuild(tpl, data) })
+		if compiled >= legacy {
+			t.Errorf("%s: expected fewer allocations than the legacy implementation, got %v >= %v", name, compiled, legacy)
+		}
+	}
+}
+
+func TestTemplateKeyBuilder_RegisterFuncAfterCompile(t *testing.T) {
+	tpl, err := Template("{val|upper}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tpl.RegisterFunc("upper", func(val string, _ ...string) (string, error) {
+		return "<" + val + ">", nil
+	})
+	key, err := tpl.Build(context.Background(), map[string]interface{}{"val": "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "<a>" {
+		t.Errorf("got %q, want %q", key, "<a>")
+	}
+
+	// Templates modified by hand are compiled on the fly
+	tpl.Template = "x:{val}"
+	key, err = tpl.Build(context.Background(), map[string]interface{}{"val": "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "x:a" {
+		t.Errorf("got %q, want %q", key, "x:a")
+	}
+}
+
+func TestTemplateKeyBuilder_RegisterFuncConcurrentBuild(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFunc("wrap", func(val string, _ ...string) (string, error) { return "<" + val + ">", nil })
+
+	tpl, err := Template("{val|wrap}", WithRegistry(r))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Register("wrapped", tpl); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				key, err := tpl.Build(context.Background(), map[string]interface{}{"val": "a"})
+				if err != nil || (key != "<a>" && key != "[a]") {
+					t.Errorf("Build() = (%q, %v)", key, err)
+					return
+				}
+			}
+		}()
+	}
+
+	for range 100 {
+		r.RegisterFunc("wrap", func(val string, _ ...string) (string, error) { return "[" + val + "]", nil })
+	}
+	wg.Wait()
+}
+
+func BenchmarkTemplateKeyBuilder_Build(b *testing.B) {
+	tpl, err := Template(benchTemplate)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for name, data := range benchInputs {
+		b.Run(name+"/compiled", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := tpl.Build(ctx, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/legacy", func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := legacyBuild(tpl, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
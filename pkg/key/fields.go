@@ -0,0 +1,382 @@
+package key
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+// fieldInfo describes a struct field bound to a template variable.
+type fieldInfo struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+	required  bool
+}
+
+// typePlan maps the template variables of a struct type to its fields.
+type typePlan struct {
+	fields map[string]*fieldInfo
+}
+
+// planCache caches the plan of each struct type, so keys are built without walking the type on every call.
+var planCache sync.Map // map[reflect.Type]*typePlan
+
+// planFor returns the plan of the struct type rt.
+func planFor(rt reflect.Type) *typePlan {
+	if p, ok := planCache.Load(rt); ok {
+		return p.(*typePlan) //nolint:forcetypeassert
+	}
+
+	p, _ := planCache.LoadOrStore(rt, buildPlan(rt))
+
+	return p.(*typePlan) //nolint:forcetypeassert
+}
+
+// parseTag parses a `kivigo:"name,omitempty,required"` struct tag.
+func parseTag(tag string) (name string, omitEmpty, required bool) {
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		switch strings.TrimSpace(opt) {
+		case "omitempty":
+			omitEmpty = true
+		case "required":
+			required = true
+		}
+	}
+
+	return strings.TrimSpace(name), omitEmpty, required
+}
+
+// buildPlan walks the fields of the struct type rt, including the fields promoted from embedded structs.
+//
+// Fields are bound under the name of their `kivigo` tag, or their own name. Unexported fields are only bound
+// if they have a tag, and fields tagged `kivigo:"-"` are skipped. As with encoding/json, when several fields
+// have the same name, the least nested one wins; if there are several at the same depth, the tagged one wins,
+// otherwise none of them is bound.
+func buildPlan(rt reflect.Type) *typePlan { //nolint:cyclop
+	type candidate struct {
+		info   *fieldInfo
+		depth  int
+		tagged bool
+	}
+
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	candidates := make(map[string][]candidate)
+	visited := make(map[reflect.Type]bool)
+	current := []embedded{{typ: rt}}
+
+	for depth := 0; len(current) > 0; depth++ {
+		var next []embedded
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := range e.typ.NumField() {
+				f := e.typ.Field(i)
+
+				tag, hasTag := f.Tag.Lookup("kivigo")
+				if tag == "-" {
+					continue
+				}
+				name, omitEmpty, required := parseTag(tag)
+				index := append(slices.Clone(e.index), i)
+
+				ft := f.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+
+				if !f.IsExported() && !hasTag {
+					continue
+				}
+
+				tagged := name != ""
+				if !tagged {
+					name = f.Name
+				}
+
+				candidates[name] = append(candidates[name], candidate{
+					info: &fieldInfo{
+						name:      name,
+						index:     index,
+						typ:       f.Type,
+						omitEmpty: omitEmpty,
+						required:  required,
+					},
+					depth:  depth,
+					tagged: tagged,
+				})
+			}
+		}
+
+		current = next
+	}
+
+	plan := &typePlan{fields: make(map[string]*fieldInfo, len(candidates))}
+
+	for name, cs := range candidates {
+		// Candidates are listed by increasing depth
+		var dominant []candidate
+		for _, c := range cs {
+			if c.depth == cs[0].depth {
+				dominant = append(dominant, c)
+			}
+		}
+
+		if len(dominant) > 1 {
+			var tagged []candidate
+			for _, c := range dominant {
+				if c.tagged {
+					tagged = append(tagged, c)
+				}
+			}
+			dominant = tagged
+		}
+
+		if len(dominant) == 1 {
+			plan.fields[name] = dominant[0].info
+		}
+	}
+
+	return plan
+}
+
+// fieldByIndex returns the field of the struct value rv at the given index.
+// Returns false if it is reached through a nil embedded pointer.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+
+	return rv, true
+}
+
+// indirect dereferences pointers and interfaces. Returns false if one of them is nil.
+func indirect(rv reflect.Value) (reflect.Value, bool) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}, false
+		}
+		rv = rv.Elem()
+	}
+
+	return rv, rv.IsValid()
+}
+
+// lookupVar returns the value of the variable name in data, which can be a struct or a map with string keys.
+// Names can be dotted paths to nested fields or map entries, like "User.ID".
+//
+// Returns false if the variable is not set, and an error if it is bound to an empty required field.
+func lookupVar(data reflect.Value, name string) (reflect.Value, bool, error) { //nolint:cyclop
+	rv := data
+	path := name
+
+	for {
+		var ok bool
+		if rv, ok = indirect(rv); !ok {
+			return reflect.Value{}, false, nil
+		}
+
+		switch rv.Kind() { //nolint:exhaustive
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false, nil
+			}
+
+			// Keys containing dots are looked up as a whole first
+			if v := rv.MapIndex(reflect.ValueOf(path).Convert(rv.Type().Key())); v.IsValid() {
+				return v, true, nil
+			}
+
+			head, rest, nested := strings.Cut(path, ".")
+			if !nested {
+				return reflect.Value{}, false, nil
+			}
+
+			v := rv.MapIndex(reflect.ValueOf(head).Convert(rv.Type().Key()))
+			if !v.IsValid() {
+				return reflect.Value{}, false, nil
+			}
+			rv, path = v, rest
+
+		case reflect.Struct:
+			head, rest, nested := strings.Cut(path, ".")
+
+			info, ok := planFor(rv.Type()).fields[head]
+			if !ok {
+				return reflect.Value{}, false, nil
+			}
+
+			v, ok := fieldByIndex(rv, info.index)
+			if ok && (info.omitEmpty || info.required) && v.IsZero() {
+				ok = false
+			}
+			if !ok {
+				if info.required {
+					return reflect.Value{}, false, fmt.Errorf("%w: required variable %q is empty", errs.ErrMissingVariable, name)
+				}
+				return reflect.Value{}, false, nil
+			}
+
+			if !nested {
+				return v, true, nil
+			}
+			rv, path = v, rest
+
+		default:
+			return reflect.Value{}, false, nil
+		}
+	}
+}
+
+// formatValue formats the value of a variable, dereferencing pointers.
+// Returns false if the value is a nil pointer or interface.
+func formatValue(rv reflect.Value) (string, bool) {
+	rv, ok := indirect(rv)
+	if !ok {
+		return "", false
+	}
+
+	// Predeclared types have no String method
+	if rt := rv.Type(); rt.PkgPath() == "" && rt.Name() != "" {
+		switch rv.Kind() { //nolint:exhaustive
+		case reflect.String:
+			return rv.String(), true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(rv.Int(), 10), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(rv.Uint(), 10), true
+		case reflect.Bool:
+			return strconv.FormatBool(rv.Bool()), true
+		}
+	}
+
+	if rv.CanInterface() {
+		return fmt.Sprintf("%v", rv.Interface()), true
+	}
+
+	// Unexported fields cannot be converted to interfaces, but fmt can print their value
+	return fmt.Sprintf("%v", rv), true
+}
+
+// hasPath reports whether the type rt has a field or entry for the dotted path.
+// Maps and interfaces are assumed to have every path, as their content is only known at runtime.
+func hasPath(rt reflect.Type, path string) bool {
+	for {
+		for rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+
+		switch rt.Kind() { //nolint:exhaustive
+		case reflect.Map, reflect.Interface:
+			return true
+		case reflect.Struct:
+			head, rest, nested := strings.Cut(path, ".")
+
+			info, ok := planFor(rt).fields[head]
+			if !ok {
+				return false
+			}
+			if !nested {
+				return true
+			}
+			rt, path = info.typ, rest
+		default:
+			return false
+		}
+	}
+}
+
+// ValidateType checks that every variable of the template is bound to a field of the struct type rt,
+// following dotted paths into nested structs. Maps and key.KeyVars implementations are always valid.
+//
+// Example:
+//
+//	tpl, _ := key.Template("user:{ID}:{Profile.Region}")
+//	err := tpl.ValidateType(reflect.TypeFor[User]())
+func (t *TemplateKeyBuilder) ValidateType(rt reflect.Type) error {
+	if rt.Implements(reflect.TypeFor[KeyVars]()) {
+		return nil
+	}
+
+	var errList []error
+
+	for _, v := range t.Variables() {
+		if !hasPath(rt, v.Name) {
+			errList = append(errList, fmt.Errorf("template variable %q is not bound to a field of %s", v.Name, rt))
+		}
+	}
+
+	return errors.Join(errList...)
+}
+
+// settableField returns the settable field of the struct value rv for the dotted path,
+// allocating the nil pointers along the way. Returns false if there is no such field.
+func settableField(rv reflect.Value, path string) (reflect.Value, bool) {
+	for {
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+
+		if rv.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+
+		head, rest, nested := strings.Cut(path, ".")
+
+		info, ok := planFor(rv.Type()).fields[head]
+		if !ok {
+			return reflect.Value{}, false
+		}
+
+		for i, x := range info.index {
+			if i > 0 && rv.Kind() == reflect.Ptr {
+				if rv.IsNil() {
+					if !rv.CanSet() {
+						return reflect.Value{}, false
+					}
+					rv.Set(reflect.New(rv.Type().Elem()))
+				}
+				rv = rv.Elem()
+			}
+			rv = rv.Field(x)
+		}
+
+		if !nested {
+			return rv, rv.CanSet()
+		}
+		path = rest
+	}
+}
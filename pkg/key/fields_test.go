@@ -0,0 +1,131 @@
+package key
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Team struct {
+	Name string `kivigo:"name"`
+}
+
+type Audit struct {
+	Tenant string
+}
+
+type Member struct {
+	*Audit
+	ID     int    `kivigo:"id,required"`
+	Region string `kivigo:"region,omitempty"`
+	Team   *Team
+	Alias  string `kivigo:"-"`
+	secret string `kivigo:"secret"`
+}
+
+func TestTemplateKeyBuilder_StructTags(t *testing.T) {
+	tpl, err := Template("{Tenant}:member:{id}:{region|default('global')}:{Team.name}:{secret}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := Member{Audit: &Audit{Tenant: "acme"}, ID: 7, Team: &Team{Name: "core"}, secret: "s"}
+
+	key, err := tpl.Build(context.Background(), &m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "acme:member:7:global:core:s" {
+		t.Errorf("got %q, want %q", key, "acme:member:7:global:core:s")
+	}
+
+	// Nil embedded and nested pointers are treated as missing
+	key, err = tpl.Build(context.Background(), Member{ID: 7, Region: "eu"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != ":member:7:eu::" {
+		t.Errorf("got %q, want %q", key, ":member:7:eu::")
+	}
+
+	if _, err := tpl.Build(context.Background(), Member{}); err == nil || !strings.Contains(err.Error(), "required") {
+		t.Errorf("expected an error for the empty required field, got %v", err)
+	}
+}
+
+func TestTemplateKeyBuilder_SkippedAndShadowedFields(t *testing.T) {
+	type Inner struct {
+		ID string
+	}
+	type Outer struct {
+		Inner
+		ID    string
+		Alias string `kivigo:"-"`
+	}
+
+	tpl, err := Template("{ID}:{Alias}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := tpl.Build(context.Background(), Outer{Inner: Inner{ID: "inner"}, ID: "outer", Alias: "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "outer:" {
+		t.Errorf("got %q, want %q", key, "outer:")
+	}
+}
+
+func TestTemplateKeyBuilder_NestedMap(t *testing.T) {
+	tpl, err := Template("user:{User.ID}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := tpl.Build(context.Background(), map[string]interface{}{"User": map[string]int{"ID": 3}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "user:3" {
+		t.Errorf("got %q, want %q", key, "user:3")
+	}
+}
+
+func TestTemplateKeyBuilder_ParseIntoTags(t *testing.T) {
+	tpl, err := Template("{Tenant}:member:{id}:{Team.name}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var m Member
+	if err := tpl.ParseInto("acme:member:7:core", &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Audit == nil || m.Tenant != "acme" || m.ID != 7 || m.Team == nil || m.Team.Name != "core" {
+		t.Errorf("unexpected result: %+v", m)
+	}
+}
+
+func TestTemplateKeyBuilder_ValidateType(t *testing.T) {
+	tpl, err := Template("{Tenant}:{id}:{Team.name}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tpl.ValidateType(reflect.TypeFor[Member]()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tpl, err = Template("{ID}:{Team.ID}:{Alias}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = tpl.ValidateType(reflect.TypeFor[Member]())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, name := range []string{`"ID"`, `"Team.ID"`, `"Alias"`} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %s in %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package key
+
+import (
+	"crypto/md5" //nolint:gosec // used for key derivation, not security
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// intArg parses the i-th argument of the function fn as an int of at least minVal.
+// Returns def if the argument is absent.
+func intArg(fn string, args []string, i, def, minVal int) (int, error) {
+	if i >= len(args) {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(args[i])
+	if err != nil {
+		return 0, fmt.Errorf("%s: cannot parse argument %q as int: %w", fn, args[i], err)
+	}
+	if n < minVal {
+		return 0, fmt.Errorf("%s: argument %d must be at least %d", fn, n, minVal)
+	}
+
+	return n, nil
+}
+
+var (
+	sha256Func = hashFunc("sha256", sha256.New)
+	md5Func    = hashFunc("md5", md5.New)
+	fnvFunc    = hashFunc("fnv", func() hash.Hash { return fnv.New64a() })
+	crc32Func  = hashFunc("crc32", func() hash.Hash { return crc32.NewIEEE() })
+
+	urlEscapeFunc = noArgs("urlescape", url.QueryEscape)
+	base64Func    = noArgs("base64", func(val string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(val))
+	})
+	hexFunc = noArgs("hex", func(val string) string {
+		return hex.EncodeToString([]byte(val))
+	})
+)
+
+// hashFunc returns a transformation function writing the hex digest of the value computed by newHash.
+// An optional argument truncates the digest to the given number of characters.
+func hashFunc(name string, newHash func() hash.Hash) TransformFunc {
+	return func(val string, args ...string) (string, error) {
+		if len(args) > 1 {
+			return "", fmt.Errorf("%s: expected at most 1 argument, got %d", name, len(args))
+		}
+
+		h := newHash()
+		h.Write([]byte(val))
+		sum := hex.EncodeToString(h.Sum(nil))
+
+		n, err := intArg(name, args, 0, len(sum), 1)
+		if err != nil {
+			return "", err
+		}
+
+		return sum[:min(n, len(sum))], nil
+	}
+}
+
+// padFunc left-pads the value to pad(width) characters with zeros, or with the character of pad(width, 'c').
+func padFunc(val string, args ...string) (string, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return "", fmt.Errorf("pad: expected 1 or 2 arguments, got %d", len(args))
+	}
+
+	width, err := intArg("pad", args, 0, 0, 0)
+	if err != nil {
+		return "", err
+	}
+
+	char := "0"
+	if len(args) == 2 {
+		if utf8.RuneCountInString(args[1]) != 1 {
+			return "", fmt.Errorf("pad: padding must be a single character, got %q", args[1])
+		}
+		char = args[1]
+	}
+
+	if n := width - utf8.RuneCountInString(val); n > 0 {
+		return strings.Repeat(char, n) + val, nil
+	}
+
+	return val, nil
+}
+
+// truncateFunc keeps the first truncate(n) characters of the value.
+func truncateFunc(val string, args ...string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("truncate: expected 1 argument, got %d", len(args))
+	}
+
+	n, err := intArg("truncate", args, 0, 0, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if utf8.RuneCountInString(val) <= n {
+		return val, nil
+	}
+
+	return string([]rune(val)[:n]), nil
+}
+
+// replaceFunc replaces every occurrence of replace(old, new) in the value.
+func replaceFunc(val string, args ...string) (string, error) {
+	if len(args) != 2 {
+		return "", fmt.Errorf("replace: expected 2 arguments, got %d", len(args))
+	}
+	if args[0] == "" {
+		return "", fmt.Errorf("replace: the string to replace cannot be empty")
+	}
+
+	return strings.ReplaceAll(val, args[0], args[1]), nil
+}
+
+// escapeFunc percent-encodes the key separators in the value, so that it can be used as a single key segment.
+// The separators are ':' and '/' by default, or the characters of escape('seps'). '%' is always encoded
+// so that the value can be unescaped with url.PathUnescape.
+func escapeFunc(val string, args ...string) (string, error) {
+	if len(args) > 1 {
+		return "", fmt.Errorf("escape: expected at most 1 argument, got %d", len(args))
+	}
+
+	seps := ":/"
+	if len(args) == 1 {
+		if args[0] == "" {
+			return "", fmt.Errorf("escape: separators cannot be empty")
+		}
+		seps = args[0]
+	}
+
+	if !strings.ContainsAny(val, seps) && !strings.Contains(val, "%") {
+		return val, nil
+	}
+
+	var b strings.Builder
+	for _, r := range val {
+		if r == '%' || strings.ContainsRune(seps, r) {
+			for _, c := range []byte(string(r)) {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String(), nil
+}
+
+// timeLayouts are the layouts accepted by the date function, starting with the format of time.Time.String.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	time.RFC3339Nano,
+	time.DateTime,
+	time.DateOnly,
+}
+
+// parseTime parses a time formatted by time.Time.String, RFC 3339, or as Unix seconds.
+func parseTime(val string) (time.Time, error) {
+	// Drop the monotonic clock reading printed by time.Time.String
+	if i := strings.Index(val, " m="); i != -1 {
+		val = val[:i]
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, nil
+		}
+	}
+
+	if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("date: cannot parse %q as a time", val)
+}
+
+// dateFunc formats a time.Time value with the layout of date('2006-01-02').
+func dateFunc(val string, args ...string) (string, error) {
+	if len(args) != 1 || args[0] == "" {
+		return "", fmt.Errorf("date: expected a layout argument")
+	}
+	if val == "" {
+		return "", nil
+	}
+
+	t, err := parseTime(val)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Format(args[0]), nil
+}
+
+// shardFunc returns the shard of the value among shard(n), from a hash of the value.
+func shardFunc(val string, args ...string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("shard: expected 1 argument, got %d", len(args))
+	}
+
+	n, err := intArg("shard", args, 0, 0, 1)
+	if err != nil {
+		return "", err
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(val))
+
+	return strconv.FormatUint(uint64(h.Sum32())%uint64(n), 10), nil
+}
+
+// uuidFunc formats 32 hexadecimal digits, with or without dashes, as a canonical lowercase UUID.
+func uuidFunc(val string, _ ...string) (string, error) {
+	digits := strings.ToLower(strings.ReplaceAll(val, "-", ""))
+	if len(digits) != 32 {
+		return "", fmt.Errorf("uuid: %q is not a UUID", val)
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "", fmt.Errorf("uuid: %q is not a UUID", val)
+	}
+
+	return digits[:8] + "-" + digits[8:12] + "-" + digits[12:16] + "-" + digits[16:20] + "-" + digits[20:], nil
+}
+
+// noArgs wraps a transformation function taking no argument, rejecting calls with arguments.
+func noArgs(name string, fn func(string) string) TransformFunc {
+	return func(val string, args ...string) (string, error) {
+		if len(args) > 0 {
+			return "", fmt.Errorf("%s: expected no argument, got %d", name, len(args))
+		}
+		return fn(val), nil
+	}
+}
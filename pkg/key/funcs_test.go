@@ -0,0 +1,96 @@
+package key
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBuiltinFuncs(t *testing.T) {
+	h := fnv.New32a()
+	h.Write([]byte("user42"))
+	shard := strconv.FormatUint(uint64(h.Sum32()%16), 10)
+
+	cases := []struct {
+		template string
+		val      any
+		want     string
+	}{
+		{"{v|sha256}", "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"{v|sha256(8)}", "abc", "ba7816bf"},
+		{"{v|md5}", "abc", "900150983cd24fb0d6963f7d28e17f72"},
+		{"{v|fnv}", "abc", "e71fa2190541574b"},
+		{"{v|crc32}", "abc", "352441c2"},
+		{"{v|crc32(100)}", "abc", "352441c2"},
+		{"{v|pad(6)}", 42, "000042"},
+		{"{v|pad(3,'x')}", "abcd", "abcd"},
+		{"{v|pad(4,'_')}", "é", "___é"},
+		{"{v|truncate(3)}", "héllo", "hél"},
+		{"{v|truncate(10)}", "abc", "abc"},
+		{"{v|replace('-','_')}", "a-b-c", "a_b_c"},
+		{"{v|urlescape}", "a b/c:d", "a+b%2Fc%3Ad"},
+		{"{v|escape}", "a:b/c%d", "a%3Ab%2Fc%25d"},
+		{"{v|escape('-')}", "a-b:c", "a%2Db:c"},
+		{"{v|date('2006-01-02')}", time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), "2024-03-05"},
+		{"{v|date('2006-01')}", time.Now().In(time.UTC), time.Now().In(time.UTC).Format("2006-01")},
+		{"{v|date('2006-01-02')}", "2024-03-05T10:00:00Z", "2024-03-05"},
+		{"{v|date('2006')}", 0, "1970"},
+		{"{v|shard(16)}", "user42", shard},
+		{"{v|base64}", "a?b", "YT9i"},
+		{"{v|hex}", "ab", "6162"},
+		{"{v|uuid}", "0190D8C5A2F47E1B9C3D4E5F60718293", "0190d8c5-a2f4-7e1b-9c3d-4e5f60718293"},
+		{"{v|uuid}", "0190d8c5-a2f4-7e1b-9c3d-4e5f60718293", "0190d8c5-a2f4-7e1b-9c3d-4e5f60718293"},
+	}
+
+	for _, tc := range cases {
+		tpl, err := Template(tc.template)
+		if err != nil {
+			t.Fatalf("unexpected error for template %q: %v", tc.template, err)
+		}
+		got, err := tpl.Build(context.Background(), map[string]interface{}{"v": tc.val})
+		if err != nil {
+			t.Errorf("template %q: unexpected error: %v", tc.template, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("template %q: got %q, want %q", tc.template, got, tc.want)
+		}
+	}
+}
+
+func TestBuiltinFuncs_InvalidArguments(t *testing.T) {
+	cases := []struct {
+		fn   string
+		val  string
+		args []string
+	}{
+		{"sha256", "abc", []string{"0"}},
+		{"md5", "abc", []string{"x"}},
+		{"fnv", "abc", []string{"1", "2"}},
+		{"pad", "1", nil},
+		{"pad", "1", []string{"-1"}},
+		{"pad", "1", []string{"4", "ab"}},
+		{"truncate", "abc", nil},
+		{"truncate", "abc", []string{"-2"}},
+		{"replace", "abc", []string{"a"}},
+		{"replace", "abc", []string{"", "b"}},
+		{"urlescape", "abc", []string{"x"}},
+		{"escape", "abc", []string{""}},
+		{"date", "2024-03-05", nil},
+		{"date", "not a date", []string{"2006"}},
+		{"shard", "abc", []string{"0"}},
+		{"shard", "abc", nil},
+		{"base64", "abc", []string{"x"}},
+		{"hex", "abc", []string{"x"}},
+		{"uuid", "abc", nil},
+		{"uuid", "zz90d8c5a2f47e1b9c3d4e5f60718293", nil},
+	}
+
+	for _, tc := range cases {
+		if _, err := builtinFuncs[tc.fn](tc.val, tc.args...); err == nil {
+			t.Errorf("%s(%q, %v): expected an error", tc.fn, tc.val, tc.args)
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package key
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of a configuration document declaring templates.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+type (
+	// Config is a configuration document declaring templates, see Registry.Load.
+	//
+	// Example in YAML:
+	//
+	//	funcs:
+	//	  short: sha256(8)
+	//	templates:
+	//	  - name: tenant
+	//	    template: "tenant:{tenant}:"
+	//	  - name: user
+	//	    template: "{@tenant}user:{id|short}"
+	//	    strict: true
+	Config struct {
+		// Funcs are function aliases registered in the registry, available to all its templates.
+		// Each alias maps a name to a call of an existing function, whose arguments are prepended to
+		// the arguments of the alias, e.g. "short": "sha256(8)".
+		Funcs map[string]string `json:"funcs,omitempty" toml:"funcs,omitempty" yaml:"funcs,omitempty"`
+
+		// Templates are the templates to register, in order.
+		Templates []TemplateSpec `json:"templates" toml:"templates" yaml:"templates"`
+	}
+
+	// TemplateSpec declares a named template in a Config.
+	TemplateSpec struct {
+		Name     string `json:"name"             toml:"name"             yaml:"name"`
+		Template string `json:"template"         toml:"template"         yaml:"template"`
+		Strict   bool   `json:"strict,omitempty" toml:"strict,omitempty" yaml:"strict,omitempty"`
+
+		// Funcs are function aliases available to this template only. See Config.Funcs.
+		Funcs map[string]string `json:"funcs,omitempty" toml:"funcs,omitempty" yaml:"funcs,omitempty"`
+	}
+)
+
+// LoadFile registers the templates declared in the file at path. The format is picked from the extension
+// of the file: .json, .yaml, .yml or .toml. See Load.
+//
+// Example:
+//
+//	err := key.GlobalRegistry().LoadFile("config/keys.yaml")
+func (r *Registry) LoadFile(path string) error {
+	var format Format
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = FormatJSON
+	case ".yaml", ".yml":
+		format = FormatYAML
+	case ".toml":
+		format = FormatTOML
+	default:
+		return fmt.Errorf("%s: unsupported template file extension", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return r.Load(data, format, path)
+}
+
+// Load registers the templates declared in a Config document. source names the document in errors,
+// which are reported with the line of the faulty template when available, e.g. "keys.yaml:12: ...".
+//
+// Either all the templates of the document are registered, or none of them.
+func (r *Registry) Load(data []byte, format Format, source string) error {
+	var (
+		cfg   Config
+		lines []int
+		err   error
+	)
+
+	switch format {
+	case FormatJSON:
+		cfg, lines, err = decodeJSONConfig(data)
+	case FormatYAML:
+		cfg, lines, err = decodeYAMLConfig(data)
+	case FormatTOML:
+		cfg, lines, err = decodeTOMLConfig(data)
+	default:
+		return fmt.Errorf("%s: unsupported format %q", source, format)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	return r.loadConfig(cfg, lines, source)
+}
+
+// loadConfig registers the templates of cfg. lines are the lines where the templates are declared in source.
+func (r *Registry) loadConfig(cfg Config, lines []int, source string) error { //nolint:cyclop
+	position := func(i int) string {
+		if i < len(lines) && lines[i] > 0 {
+			return fmt.Sprintf("%s:%d", source, lines[i])
+		}
+		return source
+	}
+
+	r.mu.RLock()
+	registryFuncs := maps.Clone(r.funcs)
+	registryAliases := maps.Clone(r.aliases)
+	r.mu.RUnlock()
+
+	docFuncs, err := resolveAliases(cfg.Funcs, registryFuncs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	// The aliases of the document are registered for every template, so they must not replace other functions
+	for _, name := range slices.Sorted(maps.Keys(cfg.Funcs)) {
+		if _, builtin := builtinFuncs[name]; builtin {
+			return fmt.Errorf("%s: function alias %q conflicts with the built-in function", source, name)
+		}
+		if _, exists := registryFuncs[name]; exists && registryAliases[name] != cfg.Funcs[name] {
+			return fmt.Errorf("%s: function alias %q conflicts with a function already registered", source, name)
+		}
+	}
+
+	seen := make(map[string]int, len(cfg.Templates))
+	templates := make([]*TemplateKeyBuilder, len(cfg.Templates))
+
+	for i, spec := range cfg.Templates {
+		if spec.Name == "" {
+			return fmt.Errorf("%s: template name cannot be empty", position(i))
+		}
+		if j, dup := seen[spec.Name]; dup {
+			return fmt.Errorf("%s: template with name '%s' already exists (declared at %s)",
+				position(i), spec.Name, position(j))
+		}
+		seen[spec.Name] = i
+
+		if origin, exists := r.origin(spec.Name); exists {
+			if origin != "" {
+				return fmt.Errorf("%s: template with name '%s' already exists (declared at %s)",
+					position(i), spec.Name, origin)
+			}
+			return fmt.Errorf("%s: template with name '%s' already exists", position(i), spec.Name)
+		}
+
+		templateFuncs, err := resolveAliases(spec.Funcs, registryFuncs, docFuncs)
+		if err != nil {
+			return fmt.Errorf("%s: template '%s': %w", position(i), spec.Name, err)
+		}
+
+		// The aliases of the template take precedence over those of the document
+		funcs := maps.Clone(docFuncs)
+		maps.Copy(funcs, templateFuncs)
+
+		opts := []TemplateOptions{WithRegistry(r), WithFuncs(funcs)}
+		if spec.Strict {
+			opts = append(opts, WithStrict())
+		}
+
+		tmpl, err := Template(spec.Template, opts...)
+		if err != nil {
+			return fmt.Errorf("%s: template '%s': %w", position(i), spec.Name, err)
+		}
+		tmpl.aliases = spec.Funcs
+		templates[i] = tmpl
+	}
+
+	for i, tmpl := range templates {
+		name := cfg.Templates[i].Name
+		if err := r.Register(name, tmpl); err != nil {
+			for _, prev := range cfg.Templates[:i] {
+				r.Delete(prev.Name)
+			}
+			return fmt.Errorf("%s: %w", position(i), err)
+		}
+
+		r.mu.Lock()
+		r.origins[name] = position(i)
+		r.mu.Unlock()
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(docFuncs)) {
+		r.RegisterFunc(name, docFuncs[name])
+
+		r.mu.Lock()
+		r.aliases[name] = cfg.Funcs[name]
+		r.mu.Unlock()
+	}
+
+	return nil
+}
+
+// origin reports whether a template is registered under name, and where it was loaded from if known.
+func (r *Registry) origin(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.templates[name]; !ok {
+		return "", false
+	}
+
+	return r.origins[name], true
+}
+
+// resolveAliases returns the functions of the aliases, resolving their targets in the built-in functions
+// and then in each of funcs.
+func resolveAliases(aliases map[string]string, funcs ...map[string]TransformFunc) (map[string]TransformFunc, error) {
+	resolved := make(map[string]TransformFunc, len(aliases))
+
+	for _, name := range slices.Sorted(maps.Keys(aliases)) {
+		fn, bound := parseFuncCall(aliases[name])
+
+		target, ok := builtinFuncs[fn]
+		for _, m := range funcs {
+			if f, found := m[fn]; found {
+				target, ok = f, true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("function alias %q: unknown transform function %q", name, fn)
+		}
+
+		resolved[name] = func(val string, args ...string) (string, error) {
+			return target(val, append(slices.Clip(bound), args...)...)
+		}
+	}
+
+	return resolved, nil
+}
+
+// Config returns the templates of the registry, sorted by name, along with the function aliases it loaded.
+// Functions registered in code cannot be represented and are omitted.
+func (r *Registry) Config() Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cfg := Config{Templates: make([]TemplateSpec, 0, len(r.templates))}
+	if len(r.aliases) > 0 {
+		cfg.Funcs = maps.Clone(r.aliases)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(r.templates)) {
+		t := r.templates[name]
+		cfg.Templates = append(cfg.Templates, TemplateSpec{
+			Name:     name,
+			Template: t.Template,
+			Strict:   t.strict,
+			Funcs:    maps.Clone(t.aliases),
+		})
+	}
+
+	return cfg
+}
+
+// Export writes the templates of the registry to w as a Config document, which can be loaded back with Load.
+//
+// Example:
+//
+//	err := registry.Export(os.Stdout, key.FormatYAML)
+func (r *Registry) Export(w io.Writer, format Format) error {
+	cfg := r.Config()
+
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cfg)
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(cfg); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatTOML:
+		return toml.NewEncoder(w).Encode(cfg)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// lineAt returns the line of the byte at offset in data.
+func lineAt(data []byte, offset int) int {
+	return bytes.Count(data[:min(offset, len(data))], []byte("\n")) + 1
+}
+
+// decodeJSONConfig decodes a JSON Config, along with the lines where its templates are declared.
+func decodeJSONConfig(data []byte) (Config, []int, error) { //nolint:cyclop
+	var (
+		cfg   Config
+		lines []int
+	)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	delim := func(want json.Delim) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok != want {
+			return fmt.Errorf("line %d: expected %q, got %v", lineAt(data, int(dec.InputOffset())), want, tok)
+		}
+		return nil
+	}
+
+	if err := delim('{'); err != nil {
+		return cfg, nil, err
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return cfg, nil, err
+		}
+
+		switch tok {
+		case "funcs":
+			if err := dec.Decode(&cfg.Funcs); err != nil {
+				return cfg, nil, fmt.Errorf("line %d: %w", lineAt(data, int(dec.InputOffset())), err)
+			}
+		case "templates":
+			if err := delim('['); err != nil {
+				return cfg, nil, err
+			}
+			for dec.More() {
+				// The offset is right after the previous token, before the separator
+				offset := int(dec.InputOffset())
+				for offset < len(data) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+					offset++
+				}
+
+				var spec TemplateSpec
+				if err := dec.Decode(&spec); err != nil {
+					return cfg, nil, fmt.Errorf("line %d: %w", lineAt(data, offset), err)
+				}
+				cfg.Templates = append(cfg.Templates, spec)
+				lines = append(lines, lineAt(data, offset))
+			}
+			if err := delim(']'); err != nil {
+				return cfg, nil, err
+			}
+		default:
+			return cfg, nil, fmt.Errorf("line %d: unknown field %v", lineAt(data, int(dec.InputOffset())), tok)
+		}
+	}
+
+	if err := delim('}'); err != nil {
+		return cfg, nil, err
+	}
+
+	return cfg, lines, nil
+}
+
+// decodeYAMLConfig decodes a YAML Config, along with the lines where its templates are declared.
+func decodeYAMLConfig(data []byte) (Config, []int, error) {
+	var cfg Config
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF { //nolint:errorlint // io.EOF is returned as is
+		return cfg, nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return cfg, nil, err
+	}
+
+	var lines []int
+
+	if len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+		root := doc.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "templates" {
+				for _, item := range root.Content[i+1].Content {
+					lines = append(lines, item.Line)
+				}
+			}
+		}
+	}
+
+	return cfg, lines, nil
+}
+
+// decodeTOMLConfig decodes a TOML Config, along with the lines where its templates are declared.
+// Lines are only known for templates declared as [[templates]] tables.
+func decodeTOMLConfig(data []byte) (Config, []int, error) {
+	var cfg Config
+
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return cfg, nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return cfg, nil, fmt.Errorf("unknown field %s", undecoded[0])
+	}
+
+	var lines []int
+
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "[[templates]]" {
+			lines = append(lines, i+1)
+		}
+	}
+	if len(lines) != len(cfg.Templates) {
+		lines = nil
+	}
+
+	return cfg, lines, nil
+}
@@ -0,0 +1,305 @@
+package key
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const yamlConfig = `funcs:
+  short: sha256(8)
+templates:
+  - name: tenant
+    template: "tenant:{tenant}:"
+  - name: user
+    template: "{@tenant}user:{id|short}"
+    strict: true
+  - name: session
+    template: "session:{sid|pad4}"
+    funcs:
+      pad4: pad(4)
+`
+
+const jsonConfig = `{
+  "funcs": {"short": "sha256(8)"},
+  "templates": [
+    {"name": "tenant", "template": "tenant:{tenant}:"},
+    {"name": "user", "template": "{@tenant}user:{id|short}", "strict": true},
+    {
+      "name": "session",
+      "template": "session:{sid|pad4}",
+      "funcs": {"pad4": "pad(4)"}
+    }
+  ]
+}`
+
+const tomlConfig = `[funcs]
+short = "sha256(8)"
+
+[[templates]]
+name = "tenant"
+template = "tenant:{tenant}:"
+
+[[templates]]
+name = "user"
+template = "{@tenant}user:{id|short}"
+strict = true
+
+[[templates]]
+name = "session"
+template = "session:{sid|pad4}"
+
+[templates.funcs]
+pad4 = "pad(4)"
+`
+
+func checkLoadedRegistry(t *testing.T, r *Registry) {
+	t.Helper()
+
+	short, _ := sha256Func("42", "8")
+
+	key, err := r.MustGet("user").Build(nil, map[string]any{"tenant": "acme", "id": 42})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "tenant:acme:user:" + short; key != want {
+		t.Errorf("got %q, want %q", key, want)
+	}
+
+	if _, err := r.MustGet("user").Build(nil, map[string]any{"tenant": "acme"}); err == nil {
+		t.Error("expected strict template to reject a missing variable")
+	}
+
+	key, err = r.MustGet("session").Build(nil, map[string]any{"sid": 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "session:0007" {
+		t.Errorf("got %q, want %q", key, "session:0007")
+	}
+}
+
+func TestRegistry_Load(t *testing.T) {
+	tests := []struct {
+		format Format
+		data   string
+	}{
+		{FormatYAML, yamlConfig},
+		{FormatJSON, jsonConfig},
+		{FormatTOML, tomlConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			r := NewRegistry()
+			if err := r.Load([]byte(tt.data), tt.format, "keys"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			checkLoadedRegistry(t, r)
+		})
+	}
+}
+
+func TestRegistry_LoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "keys.yml")
+	if err := os.WriteFile(path, []byte(yamlConfig), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := NewRegistry()
+	if err := r.LoadFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkLoadedRegistry(t, r)
+
+	if err := r.LoadFile(filepath.Join(dir, "keys.ini")); err == nil {
+		t.Error("expected error for unsupported extension")
+	}
+}
+
+func TestRegistry_LoadDuplicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		want   string
+	}{
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			data:   "templates:\n  - name: a\n    template: \"a:{id}\"\n  - name: a\n    template: \"b:{id}\"\n",
+			want:   "keys.yaml:4: template with name 'a' already exists (declared at keys.yaml:2)",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			data:   "{\"templates\": [\n  {\"name\": \"a\", \"template\": \"a:{id}\"},\n  {\"name\": \"a\", \"template\": \"b:{id}\"}\n]}",
+			want:   "keys.json:3: template with name 'a' already exists (declared at keys.json:2)",
+		},
+		{
+			name:   "toml",
+			format: FormatTOML,
+			data:   "[[templates]]\nname = \"a\"\ntemplate = \"a:{id}\"\n\n[[templates]]\nname = \"a\"\ntemplate = \"b:{id}\"\n",
+			want:   "keys.toml:5: template with name 'a' already exists (declared at keys.toml:1)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			err := r.Load([]byte(tt.data), tt.format, "keys."+tt.name)
+			if err == nil || err.Error() != tt.want {
+				t.Fatalf("got %v, want %q", err, tt.want)
+			}
+			if _, ok := r.Get("a"); ok {
+				t.Error("expected no template to be registered")
+			}
+		})
+	}
+}
+
+func TestRegistry_LoadAcrossFiles(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Load([]byte(yamlConfig), FormatYAML, "base.yaml"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := r.Load([]byte("templates:\n  - name: other\n    template: \"o:{id}\"\n  - name: user\n    template: \"u:{id}\"\n"),
+		FormatYAML, "extra.yaml")
+	want := "extra.yaml:4: template with name 'user' already exists (declared at base.yaml:6)"
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %q", err, want)
+	}
+	if _, ok := r.Get("other"); ok {
+		t.Error("expected the templates of the failed file not to be registered")
+	}
+
+	// Templates registered in code cannot replace templates loaded from files either
+	tmpl, _ := Template("u:{id}")
+	err = r.Register("user", tmpl)
+	want = "template with name 'user' already exists (declared at base.yaml:6)"
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %q", err, want)
+	}
+
+	tmpl, _ = Template("code:{id}")
+	_ = r.Register("code", tmpl)
+
+	err = r.Load([]byte("templates:\n  - name: code\n    template: \"c:{id}\"\n"), FormatYAML, "extra.yaml")
+	want = "extra.yaml:2: template with name 'code' already exists"
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %q", err, want)
+	}
+}
+
+func TestRegistry_LoadFuncs(t *testing.T) {
+	r := NewRegistry()
+	err := r.Load([]byte(`funcs:
+  short: sha256(8)
+templates:
+  - name: a
+    template: "a:{id|short}"
+    funcs:
+      short: pad(4)
+  - name: b
+    template: "b:{id|short}"
+`), FormatYAML, "keys")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The aliases of a template take precedence over the aliases of the document
+	key, err := r.MustGet("a").Build(context.Background(), map[string]interface{}{"id": 7})
+	if err != nil || key != "a:0007" {
+		t.Errorf("got (%q, %v), want %q", key, err, "a:0007")
+	}
+	key, err = r.MustGet("b").Build(context.Background(), map[string]interface{}{"id": 7})
+	if err != nil || len(key) != len("b:")+8 {
+		t.Errorf("got (%q, %v), want a hash of 8 characters", key, err)
+	}
+
+	// Loading the same alias again is allowed, but aliases cannot replace other functions
+	if err := r.Load([]byte("funcs:\n  short: sha256(8)\n"), FormatYAML, "same.yaml"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	r.RegisterFunc("code", func(val string, _ ...string) (string, error) { return val, nil })
+
+	for _, data := range []string{
+		"funcs:\n  short: sha256(4)\n",
+		"funcs:\n  code: pad(4)\n",
+		"funcs:\n  upper: lower\n",
+	} {
+		err := r.Load([]byte(data), FormatYAML, "other.yaml")
+		if err == nil || !strings.Contains(err.Error(), "conflicts with") {
+			t.Errorf("Load(%q): expected a conflict error, got %v", data, err)
+		}
+	}
+}
+
+func TestRegistry_LoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		data   string
+		want   string
+	}{
+		{"unknown field", FormatYAML, "templates:\n  - name: a\n    tmpl: \"a:{id}\"\n", "field tmpl not found"},
+		{"unknown json field", FormatJSON, `{"version": 1}`, "unknown field version"},
+		{"unknown toml field", FormatTOML, "[[templates]]\nname = \"a\"\ntmpl = \"a:{id}\"\n", "unknown field templates.tmpl"},
+		{"empty name", FormatYAML, "templates:\n  - template: \"a:{id}\"\n", "keys:2: template name cannot be empty"},
+		{"invalid template", FormatYAML, "templates:\n  - name: a\n    template: \"a:{id|nope}\"\n", "keys:2: template 'a'"},
+		{"unknown alias target", FormatYAML, "funcs:\n  short: nope(8)\n", "unknown transform function \"nope\""},
+		{"reference cycle", FormatYAML,
+			"templates:\n  - name: a\n    template: \"{@b}a\"\n  - name: b\n    template: \"{@a}b\"\n", "keys:4: cannot register template 'b'"},
+		{"unsupported format", Format("xml"), "", "unsupported format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			err := r.Load([]byte(tt.data), tt.format, "keys")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want error containing %q", err, tt.want)
+			}
+			if _, ok := r.Get("a"); ok {
+				t.Error("expected no template to be registered")
+			}
+		})
+	}
+}
+
+func TestRegistry_Export(t *testing.T) {
+	for _, format := range []Format{FormatYAML, FormatJSON, FormatTOML} {
+		t.Run(string(format), func(t *testing.T) {
+			r := NewRegistry()
+			if err := r.Load([]byte(yamlConfig), FormatYAML, "keys.yaml"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := r.Export(&buf, format); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			loaded := NewRegistry()
+			if err := loaded.Load(buf.Bytes(), format, "export"); err != nil {
+				t.Fatalf("unexpected error loading export: %v\n%s", err, buf.String())
+			}
+			checkLoadedRegistry(t, loaded)
+
+			cfg := loaded.Config()
+			if len(cfg.Templates) != 3 || cfg.Templates[0].Name != "session" || cfg.Templates[2].Name != "user" {
+				t.Errorf("unexpected templates: %+v", cfg.Templates)
+			}
+			if cfg.Funcs["short"] != "sha256(8)" {
+				t.Errorf("got %q, want %q", cfg.Funcs["short"], "sha256(8)")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package key
+
+type (
+	// TemplateOption configures a TemplateKeyBuilder created by Template.
+	TemplateOption struct {
+		// Strict makes Build return errs.ErrMissingVariable when a variable is missing or empty,
+		// unless its placeholder has a default transform.
+		Strict bool
+
+		// Funcs are custom transformation functions available to the template, in addition to the built-in ones.
+		Funcs map[string]TransformFunc
+
+		// Registry is the registry owning the template, whose functions are available to the template.
+		Registry *Registry
+	}
+
+	// TemplateOptions is a function that modifies the TemplateOption.
+	TemplateOptions func(TemplateOption) TemplateOption
+)
+
+// WithStrict returns a TemplateOptions that makes Build fail on missing or empty variables
+// instead of substituting an empty string. Placeholders with a default transform are still allowed
+// to be missing.
+//
+// Example:
+//
+//	tpl, err := key.Template("user:{userID}:data:{dataID}", key.WithStrict())
+func WithStrict() TemplateOptions {
+	return func(o TemplateOption) TemplateOption {
+		o.Strict = true
+		return o
+	}
+}
+
+// WithFuncs returns a TemplateOptions that registers custom transformation functions in the template.
+//
+// Example:
+//
+//	tpl, err := key.Template("user:{name|reverse}", key.WithFuncs(map[string]key.TransformFunc{"reverse": reverse}))
+func WithFuncs(funcs map[string]TransformFunc) TemplateOptions {
+	return func(o TemplateOption) TemplateOption {
+		if o.Funcs == nil {
+			o.Funcs = make(map[string]TransformFunc, len(funcs))
+		}
+		for name, fn := range funcs {
+			o.Funcs[name] = fn
+		}
+		return o
+	}
+}
+
+// WithRegistry returns a TemplateOptions that makes the functions of the registry available to the template,
+// so that they are known when the template is validated. The template is not registered.
+//
+// Example:
+//
+//	tpl, err := key.Template("user:{name|reverse}", key.WithRegistry(registry))
+//	err = registry.Register("user", tpl)
+func WithRegistry(r *Registry) TemplateOptions {
+	return func(o TemplateOption) TemplateOption {
+		o.Registry = r
+		return o
+	}
+}
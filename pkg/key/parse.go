@@ -0,0 +1,195 @@
+package key
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// templateSegment is a literal part or a placeholder of a template.
+type templateSegment struct {
+	literal string
+	token   string // the placeholder without braces, empty for literals
+}
+
+// segments splits the template into literal parts and placeholders.
+func segments(tmpl string) []templateSegment {
+	var segs []templateSegment
+
+	for {
+		op := strings.Index(tmpl, "{")
+		if op == -1 {
+			break
+		}
+		cl := strings.Index(tmpl[op:], "}")
+		if cl == -1 {
+			break
+		}
+		if op > 0 {
+			segs = append(segs, templateSegment{literal: tmpl[:op]})
+		}
+		segs = append(segs, templateSegment{token: tmpl[op+1 : op+cl]})
+		tmpl = tmpl[op+cl+1:]
+	}
+
+	if tmpl != "" {
+		segs = append(segs, templateSegment{literal: tmpl})
+	}
+
+	return segs
+}
+
+// checkAmbiguity returns an error if two placeholders of the template are not separated by a literal,
+// as keys built from such templates cannot be parsed back. References to other templates are checked
+// once they are resolved, see checkFlatAmbiguity.
+func checkAmbiguity(tmpl string) error {
+	segs := segments(tmpl)
+	for i := 1; i < len(segs); i++ {
+		if strings.HasPrefix(segs[i].token, refMarker) || strings.HasPrefix(segs[i-1].token, refMarker) {
+			continue
+		}
+		if segs[i].token != "" && segs[i-1].token != "" {
+			return fmt.Errorf("template is ambiguous: placeholders {%s} and {%s} must be separated by a literal",
+				segs[i-1].token, segs[i].token)
+		}
+	}
+
+	return nil
+}
+
+// compilePattern returns a regular expression matching the keys built from the template,
+// capturing the value of each placeholder in order.
+func compilePattern(tmpl string) (*regexp.Regexp, error) {
+	var b strings.Builder
+
+	b.WriteString("^")
+	for _, seg := range segments(tmpl) {
+		if seg.token == "" {
+			b.WriteString(regexp.QuoteMeta(seg.literal))
+		} else {
+			b.WriteString("(.+?)")
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
+
+// Parse extracts the values of the template variables from a key built from the template.
+//
+// Values are returned as they appear in the key, i.e. after their transformations.
+// A variable appearing several times in the template must have the same value at each occurrence.
+//
+// Example:
+//
+//	tpl, _ := key.Template("user:{userID}:data:{dataID}")
+//	vars, err := tpl.Parse("user:42:data:abc") // {"userID": "42", "dataID": "abc"}
+func (t *TemplateKeyBuilder) Parse(key string) (map[string]string, error) {
+	pattern, variables, err := t.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	match := pattern.FindStringSubmatch(key)
+	if match == nil {
+		return nil, fmt.Errorf("key %q does not match template %q", key, t.Template)
+	}
+
+	vars := make(map[string]string)
+
+	for i, v := range variables {
+		value := match[i+1]
+		if prev, ok := vars[v.Name]; ok && prev != value {
+			return nil, fmt.Errorf("key %q has conflicting values for variable %q", key, v.Name)
+		}
+		vars[v.Name] = value
+	}
+
+	return vars, nil
+}
+
+// ParseInto extracts the values of the template variables from the key and stores them in the fields
+// of the struct pointed to by dest, bound as in Build. Values are converted to the type of the fields, which
+// can be strings, booleans, numbers, pointers to them or implement encoding.TextUnmarshaler.
+// Variables without a matching field are ignored.
+//
+// Example:
+//
+//	var ref struct {
+//	    UserID int    `kivigo:"userID"`
+//	    DataID string `kivigo:"dataID"`
+//	}
+//	err := tpl.ParseInto("user:42:data:abc", &ref)
+func (t *TemplateKeyBuilder) ParseInto(key string, dest any) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("destination must be a non-nil pointer to a struct, got %T", dest)
+	}
+
+	vars, err := t.Parse(key)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range vars {
+		field, ok := settableField(rv, name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("cannot set field %s from %q: %w", name, value, err)
+		}
+	}
+
+	return nil
+}
+
+// setField converts value to the type of the field and sets it.
+func setField(field reflect.Value, value string) error { //nolint:cyclop
+	if field.CanAddr() {
+		if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(value))
+		}
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setField(field.Elem(), value)
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package key
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTemplateKeyBuilder_Parse(t *testing.T) {
+	tpl, err := Template("user:{userID}:data:{dataID}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		key     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"user:42:data:abc", map[string]string{"userID": "42", "dataID": "abc"}, false},
+		{"user:42:data:a:b", map[string]string{"userID": "42", "dataID": "a:b"}, false},
+		{"user:a:b:data:c", map[string]string{"userID": "a:b", "dataID": "c"}, false},
+		{"user:42:data:", nil, true},
+		{"user:42", nil, true},
+		{"session:42:data:abc", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := tpl.Parse(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if len(got) != len(tt.want) || got["userID"] != tt.want["userID"] || got["dataID"] != tt.want["dataID"] {
+			t.Errorf("Parse(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestTemplateKeyBuilder_ParseRoundTrip(t *testing.T) {
+	tpl, err := Template("order/{region|upper}/{id}/items")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := tpl.Build(context.Background(), map[string]interface{}{"region": "eu", "id": 7})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vars, err := tpl.Parse(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vars["region"] != "EU" || vars["id"] != "7" {
+		t.Errorf("unexpected variables: %v", vars)
+	}
+}
+
+func TestTemplateKeyBuilder_ParseRepeatedVariable(t *testing.T) {
+	tpl, err := Template("{id}:copy:{id}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tpl.Parse("1:copy:1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := tpl.Parse("1:copy:2"); err == nil {
+		t.Error("expected an error for conflicting values")
+	}
+}
+
+func TestTemplateKeyBuilder_ParseInto(t *testing.T) {
+	tpl, err := Template("user:{UserID}:data:{DataID}:{Active}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ref struct {
+		UserID uint16
+		DataID string
+		Active bool
+	}
+	if err := tpl.ParseInto("user:42:data:abc:true", &ref); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ref.UserID != 42 || ref.DataID != "abc" || !ref.Active {
+		t.Errorf("unexpected result: %+v", ref)
+	}
+
+	if err := tpl.ParseInto("user:x:data:abc:true", &ref); err == nil {
+		t.Error("expected a conversion error")
+	}
+	if err := tpl.ParseInto("user:42:data:abc:true", ref); err == nil {
+		t.Error("expected an error for a non-pointer destination")
+	}
+}
+
+func TestTemplate_Ambiguous(t *testing.T) {
+	if _, err := Template("user:{first}{last}"); err == nil {
+		t.Error("expected an error for adjacent placeholders")
+	}
+	if _, err := Template("user:{first}-{last}"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
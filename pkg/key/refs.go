@@ -0,0 +1,271 @@
+package key
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kivigo/kivigo/pkg/errs"
+)
+
+// refMarker starts the placeholders referencing other registered templates, e.g. {@tenant}.
+const refMarker = "@"
+
+// maxRefDepth bounds the nesting of template references when building keys.
+const maxRefDepth = 32
+
+// errRefCycle is returned when templates reference each other.
+var errRefCycle = errors.New("template reference cycle")
+
+type (
+	// flatNode is a node of a template with its references expanded, along with the template it belongs to.
+	flatNode struct {
+		owner *TemplateKeyBuilder
+		n     *node
+	}
+
+	// flatCache is the pattern and variables of a template with references, for a generation of its registry.
+	flatCache struct {
+		src     string
+		gen     uint64
+		pattern *regexp.Regexp
+		vars    []Variable
+	}
+
+	// lookupFunc returns the template registered under name.
+	lookupFunc func(name string) (*TemplateKeyBuilder, bool)
+)
+
+// lookup returns the function resolving the references of the template through its registry.
+func (t *TemplateKeyBuilder) lookup() lookupFunc {
+	if t.registry == nil {
+		return func(string) (*TemplateKeyBuilder, bool) { return nil, false }
+	}
+
+	return t.registry.Get
+}
+
+// displayName returns the name of the template if it is registered, or the template itself.
+func (t *TemplateKeyBuilder) displayName() string {
+	if t.name != "" {
+		return t.name
+	}
+
+	return fmt.Sprintf("%q", t.Template)
+}
+
+// resolveRef returns the template referenced by {@name} in the template.
+func (t *TemplateKeyBuilder) resolveRef(name string, depth int) (*TemplateKeyBuilder, error) {
+	if depth >= maxRefDepth {
+		return nil, fmt.Errorf("%w: references are nested too deeply at {%s%s}", errRefCycle, refMarker, name)
+	}
+	if t.registry == nil {
+		return nil, fmt.Errorf("cannot resolve {%s%s}: template %s is not registered", refMarker, name, t.displayName())
+	}
+
+	ref, ok := t.registry.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown template reference {%s%s}", refMarker, name)
+	}
+
+	return ref, nil
+}
+
+// flatten returns the nodes of the template with its references expanded through lookup.
+// With partial, unknown references are kept instead of returning an error. Cycles are always reported.
+func (t *TemplateKeyBuilder) flatten(lookup lookupFunc, partial bool) ([]flatNode, error) {
+	return t.flattenInto(nil, lookup, []*TemplateKeyBuilder{t}, []string{t.displayName()}, partial)
+}
+
+// flattenInto appends the expanded nodes of the template to dst. path lists the templates being expanded,
+// and names their names, to detect and report cycles.
+func (t *TemplateKeyBuilder) flattenInto(
+	dst []flatNode, lookup lookupFunc, path []*TemplateKeyBuilder, names []string, partial bool,
+) ([]flatNode, error) {
+	prog, err := t.program()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range prog.nodes {
+		n := &prog.nodes[i]
+		if n.ref == "" {
+			dst = append(dst, flatNode{owner: t, n: n})
+			continue
+		}
+
+		ref, ok := lookup(n.ref)
+		if !ok {
+			if partial {
+				dst = append(dst, flatNode{owner: t, n: n})
+				continue
+			}
+			return nil, fmt.Errorf("unknown template reference {%s%s}", refMarker, n.ref)
+		}
+
+		refNames := append(slices.Clip(names), n.ref)
+		if slices.Contains(path, ref) {
+			return nil, fmt.Errorf("%w: %s", errRefCycle, strings.Join(refNames, " -> "))
+		}
+
+		dst, err = ref.flattenInto(dst, lookup, append(slices.Clip(path), ref), refNames, partial)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dst, nil
+}
+
+// flattenPartial returns the nodes of the template with the references that can be resolved expanded.
+// If the references cannot be expanded, only the nodes of the template itself are returned.
+func (t *TemplateKeyBuilder) flattenPartial() []flatNode {
+	if nodes, err := t.flatten(t.lookup(), true); err == nil {
+		return nodes
+	}
+
+	prog, err := t.program()
+	if err != nil {
+		return nil
+	}
+
+	nodes := make([]flatNode, len(prog.nodes))
+	for i := range prog.nodes {
+		nodes[i] = flatNode{owner: t, n: &prog.nodes[i]}
+	}
+
+	return nodes
+}
+
+// checkFlatAmbiguity returns an error if two placeholders of the expanded template are not separated by a literal.
+func checkFlatAmbiguity(nodes []flatNode) error {
+	for i := 1; i < len(nodes); i++ {
+		if nodes[i].n.isVar && nodes[i-1].n.isVar {
+			return fmt.Errorf("template is ambiguous: placeholders {%s} and {%s} must be separated by a literal",
+				nodes[i-1].n.name, nodes[i].n.name)
+		}
+	}
+
+	return nil
+}
+
+// matcher returns the pattern matching the keys built from the template and the variables it captures, in order.
+func (t *TemplateKeyBuilder) matcher() (*regexp.Regexp, []Variable, error) {
+	prog, err := t.program()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !prog.hasRefs {
+		return prog.pattern, t.Variables(), nil
+	}
+
+	// The expansion of the references changes when templates are registered or deleted
+	var gen uint64
+	if t.registry != nil {
+		gen = t.registry.gen.Load()
+	}
+
+	t.flatMu.Lock()
+	defer t.flatMu.Unlock()
+
+	if t.flat != nil && t.flat.src == t.Template && t.flat.gen == gen {
+		return t.flat.pattern, t.flat.vars, nil
+	}
+
+	nodes, err := t.flatten(t.lookup(), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkFlatAmbiguity(nodes); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		b    strings.Builder
+		vars []Variable
+	)
+
+	b.WriteString("^")
+	for _, fn := range nodes {
+		if fn.n.isVar {
+			b.WriteString("(.+?)")
+			vars = append(vars, Variable{Name: fn.n.name, Transforms: fn.n.transforms})
+		} else {
+			b.WriteString(regexp.QuoteMeta(fn.n.literal))
+		}
+	}
+	b.WriteString("$")
+
+	pattern, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t.flat = &flatCache{src: t.Template, gen: gen, pattern: pattern, vars: vars}
+
+	return pattern, vars, nil
+}
+
+// specificity returns the length of the literal parts of the template, including those of the templates
+// it references. When several templates match a key, the most specific one is preferred.
+func (t *TemplateKeyBuilder) specificity() int {
+	n := 0
+	for _, fn := range t.flattenPartial() {
+		n += len(fn.n.literal)
+	}
+
+	return n
+}
+
+// Prefix builds the longest prefix of the keys of the template from a partial set of variables:
+// the key is built up to the first placeholder whose variable is missing or empty.
+// The prefix can be passed to List or Iterate to retrieve the keys sharing these variables.
+//
+// Example:
+//
+//	tpl, _ := key.Template("tenant:{tenant}:user:{id}:session:{sid}")
+//	prefix, err := tpl.Prefix(ctx, map[string]any{"tenant": "acme"}) // "tenant:acme:user:"
+func (t *TemplateKeyBuilder) Prefix(ctx context.Context, data any) (string, error) {
+	if v, ok := data.(KeyVars); ok {
+		data = v.KeyVars()
+	}
+
+	var root reflect.Value
+	if _, ok := data.(map[string]interface{}); !ok {
+		root = reflect.ValueOf(data)
+	}
+
+	nodes, err := t.flatten(t.lookup(), false)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	for _, fn := range nodes {
+		if !fn.n.isVar {
+			b.WriteString(fn.n.literal)
+			continue
+		}
+
+		sval, err := lookupString(data, root, fn.n.name, fn.n.nested)
+		if errors.Is(err, errs.ErrMissingVariable) || (err == nil && sval == "") {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		val, err := fn.owner.eval(fn.n, data, root)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(val)
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,193 @@
+package key
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func newLayeredRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	r := NewRegistry()
+
+	tenant, err := Template("tenant:{tenant}:")
+	if err != nil {
+		t.Fatalf("unexpected error on Template: %v", err)
+	}
+	if err := r.Register("tenant", tenant); err != nil {
+		t.Fatalf("unexpected error on Register: %v", err)
+	}
+	if _, err := r.Extend("user", "tenant", "user:{id}"); err != nil {
+		t.Fatalf("unexpected error on Extend: %v", err)
+	}
+	if _, err := r.Extend("session", "user", ":session:{sid|upper}"); err != nil {
+		t.Fatalf("unexpected error on Extend: %v", err)
+	}
+
+	return r
+}
+
+func TestRegistry_References(t *testing.T) {
+	r := newLayeredRegistry(t)
+	session := r.MustGet("session")
+	ctx := context.Background()
+
+	key, err := session.Build(ctx, map[string]interface{}{"tenant": "acme", "id": 42, "sid": "s1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "tenant:acme:user:42:session:S1" {
+		t.Errorf("got %q, want %q", key, "tenant:acme:user:42:session:S1")
+	}
+
+	vars, err := session.Parse(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if vars["tenant"] != "acme" || vars["id"] != "42" || vars["sid"] != "S1" {
+		t.Errorf("unexpected variables: %v", vars)
+	}
+
+	names := make([]string, 0, 3)
+	for _, v := range session.Variables() {
+		names = append(names, v.Name)
+	}
+	if strings.Join(names, ",") != "tenant,id,sid" {
+		t.Errorf("unexpected variables: %v", names)
+	}
+	if got := session.StaticPrefix(); got != "tenant:" {
+		t.Errorf("got %q, want %q", got, "tenant:")
+	}
+
+	name, _, ok := r.Match(key)
+	if !ok || name != "session" {
+		t.Errorf("Match() = (%q, %v), want session", name, ok)
+	}
+}
+
+func TestTemplateKeyBuilder_Prefix(t *testing.T) {
+	session := newLayeredRegistry(t).MustGet("session")
+	ctx := context.Background()
+
+	cases := []struct {
+		data map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{}, "tenant:"},
+		{map[string]interface{}{"tenant": "acme"}, "tenant:acme:user:"},
+		{map[string]interface{}{"tenant": "acme", "id": 42}, "tenant:acme:user:42:session:"},
+		{map[string]interface{}{"tenant": "acme", "sid": "s1"}, "tenant:acme:user:"},
+		{map[string]interface{}{"tenant": "acme", "id": 42, "sid": "s1"}, "tenant:acme:user:42:session:S1"},
+	}
+
+	for _, tc := range cases {
+		got, err := session.Prefix(ctx, tc.data)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("Prefix(%v) = %q, want %q", tc.data, got, tc.want)
+		}
+	}
+
+	type ref struct {
+		Tenant string `kivigo:"tenant,required"`
+		ID     int    `kivigo:"id,omitempty"`
+	}
+	got, err := session.Prefix(ctx, ref{Tenant: "acme"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "tenant:acme:user:" {
+		t.Errorf("got %q, want %q", got, "tenant:acme:user:")
+	}
+}
+
+func TestRegistry_LateReference(t *testing.T) {
+	r := NewRegistry()
+
+	a, err := Template("a:{@b}:{x}")
+	if err != nil {
+		t.Fatalf("unexpected error on Template: %v", err)
+	}
+	if err := r.Register("a", a); err != nil {
+		t.Fatalf("unexpected error on Register: %v", err)
+	}
+	if _, err := a.Build(context.Background(), nil); err == nil {
+		t.Error("expected an error for an unknown reference")
+	}
+
+	b, err := Template("b{y}")
+	if err != nil {
+		t.Fatalf("unexpected error on Template: %v", err)
+	}
+	if err := r.Register("b", b); err != nil {
+		t.Fatalf("unexpected error on Register: %v", err)
+	}
+
+	key, err := a.Build(context.Background(), map[string]interface{}{"x": 1, "y": 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "a:b2:1" {
+		t.Errorf("got %q, want %q", key, "a:b2:1")
+	}
+	if vars, err := a.Parse(key); err != nil || vars["y"] != "2" {
+		t.Errorf("Parse() = (%v, %v)", vars, err)
+	}
+
+	// Unregistered templates cannot resolve references
+	c, err := Template("c:{@b}")
+	if err != nil {
+		t.Fatalf("unexpected error on Template: %v", err)
+	}
+	if _, err := c.Build(context.Background(), nil); err == nil {
+		t.Error("expected an error for an unregistered template")
+	}
+}
+
+func TestRegistry_ReferenceCycles(t *testing.T) {
+	r := NewRegistry()
+
+	register := func(name, tmpl string) error {
+		b, err := Template(tmpl)
+		if err != nil {
+			t.Fatalf("unexpected error on Template: %v", err)
+		}
+		return r.Register(name, b)
+	}
+
+	if err := register("self", "self:{@self}"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+
+	if err := register("x", "x:{@y}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := register("z", "z:{@x}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := register("y", "y:{@z}")
+	if err == nil || !strings.Contains(err.Error(), "y -> z -> x -> y") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+	if _, ok := r.Get("y"); ok {
+		t.Error("expected the template to be rejected")
+	}
+
+	// References making a template ambiguous are rejected as well
+	if err := register("v", "v:{id}"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := register("w", "{@v}{other}"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("expected an ambiguity error, got %v", err)
+	}
+
+	if _, err := Template("{@v|upper}"); err == nil {
+		t.Error("expected an error for a reference with transforms")
+	}
+	if _, err := r.Extend("u", "missing", ":u"); err == nil {
+		t.Error("expected an error for a missing base template")
+	}
+}
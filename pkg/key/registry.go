@@ -1,8 +1,10 @@
 package key
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 )
 
 // globalRegistry is a package-level registry for shared templates.
@@ -18,6 +20,13 @@ type Registry struct {
 	mu        sync.RWMutex
 	templates map[string]*TemplateKeyBuilder
 	funcs     map[string]TransformFunc // registry-level functions
+
+	// origins and aliases record where the templates and function aliases were loaded from, see Load.
+	origins map[string]string
+	aliases map[string]string
+
+	// gen changes every time a template is registered or deleted, invalidating the expanded references.
+	gen atomic.Uint64
 }
 
 // NewRegistry creates a new empty registry.
@@ -25,32 +34,100 @@ func NewRegistry() *Registry {
 	return &Registry{
 		templates: make(map[string]*TemplateKeyBuilder),
 		funcs:     make(map[string]TransformFunc),
+		origins:   make(map[string]string),
+		aliases:   make(map[string]string),
 	}
 }
 
 // Register adds a named template and injects all registry-level functions into it.
+//
+// The template can reference other templates of the registry with {@name}, even if they are registered later.
+// Returns an error if the references form a cycle, or if the expanded template is ambiguous.
 func (r *Registry) Register(name string, tmpl *TemplateKeyBuilder) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if _, exists := r.templates[name]; exists {
+		if origin := r.origins[name]; origin != "" {
+			return fmt.Errorf("template with name '%s' already exists (declared at %s)", name, origin)
+		}
 		return fmt.Errorf("template with name '%s' already exists", name)
 	}
-	// Inject all registry-level funcs into the template
+	if err := r.checkRefs(name, tmpl); err != nil {
+		return err
+	}
+	// Inject all registry-level funcs into the template, except those its own aliases override
 	for fname, fn := range r.funcs {
-		tmpl.RegisterFunc(fname, fn)
+		if _, ok := tmpl.aliases[fname]; !ok {
+			tmpl.RegisterFunc(fname, fn)
+		}
 	}
+	tmpl.name, tmpl.registry = name, r
 	r.templates[name] = tmpl
+	r.gen.Add(1)
+	return nil
+}
+
+// checkRefs checks the references of a template about to be registered under name. Must be called with the lock held.
+func (r *Registry) checkRefs(name string, tmpl *TemplateKeyBuilder) error {
+	lookup := func(n string) (*TemplateKeyBuilder, bool) {
+		if n == name {
+			return tmpl, true
+		}
+		t, ok := r.templates[n]
+		return t, ok
+	}
+
+	nodes, err := tmpl.flattenInto(nil, lookup, []*TemplateKeyBuilder{tmpl}, []string{name}, true)
+	if err != nil {
+		if errors.Is(err, errRefCycle) {
+			return fmt.Errorf("cannot register template '%s': %w", name, err)
+		}
+		return err
+	}
+
+	if err := checkFlatAmbiguity(nodes); err != nil {
+		return fmt.Errorf("cannot register template '%s': %w", name, err)
+	}
+
 	return nil
 }
 
+// Extend creates a template made of the template registered under base followed by suffix,
+// and registers it under name.
+//
+// Example:
+//
+//	tenant, _ := key.Template("tenant:{tenant}:")
+//	_ = registry.Register("tenant", tenant)
+//	user, err := registry.Extend("user", "tenant", "user:{id}") // "tenant:{tenant}:user:{id}"
+func (r *Registry) Extend(name, base, suffix string, opts ...TemplateOptions) (*TemplateKeyBuilder, error) {
+	if _, ok := r.Get(base); !ok {
+		return nil, fmt.Errorf("template with name '%s' not found", base)
+	}
+
+	tmpl, err := Template("{"+refMarker+base+"}"+suffix, append(opts, WithRegistry(r))...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Register(name, tmpl); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
 // RegisterFunc registers a transformation function globally for all templates in the registry.
+// Templates loaded with a function alias of the same name keep their alias, see Load.
 func (r *Registry) RegisterFunc(name string, fn TransformFunc) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.funcs[name] = fn
-	// Inject into all existing templates
+	// Inject into all existing templates, except those overriding it with an alias
 	for _, tmpl := range r.templates {
-		tmpl.RegisterFunc(name, fn)
+		if _, ok := tmpl.aliases[name]; !ok {
+			tmpl.RegisterFunc(name, fn)
+		}
 	}
 }
 
@@ -80,4 +157,42 @@ func (r *Registry) Delete(name string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.templates, name)
+	delete(r.origins, name)
+	r.gen.Add(1)
+}
+
+// Match finds the registered template the key was built from and extracts its variables.
+// If several templates match, the one with the longest literal parts is returned, then the first by name.
+// Returns false if no template matches.
+//
+// Example:
+//
+//	name, vars, ok := registry.Match("user:42:data:abc")
+func (r *Registry) Match(key string) (string, map[string]string, bool) {
+	// Parsing resolves the references of the templates through the registry, so it must not hold the lock
+	r.mu.RLock()
+	templates := make(map[string]*TemplateKeyBuilder, len(r.templates))
+	for name, tmpl := range r.templates {
+		templates[name] = tmpl
+	}
+	r.mu.RUnlock()
+
+	var (
+		bestName string
+		bestVars map[string]string
+		bestSpec = -1
+	)
+
+	for name, tmpl := range templates {
+		vars, err := tmpl.Parse(key)
+		if err != nil {
+			continue
+		}
+		spec := tmpl.specificity()
+		if spec > bestSpec || (spec == bestSpec && name < bestName) {
+			bestName, bestVars, bestSpec = name, vars, spec
+		}
+	}
+
+	return bestName, bestVars, bestSpec >= 0
 }
@@ -71,3 +71,60 @@ func TestGlobalRegistry(t *testing.T) {
 		t.Fatal("expected global template to be deleted")
 	}
 }
+
+func TestRegistry_Match(t *testing.T) {
+	r := NewRegistry()
+	for name, tmpl := range map[string]string{
+		"user":      "user:{id}",
+		"user_data": "user:{id}:data:{dataID}",
+		"session":   "session:{id}",
+	} {
+		b, err := Template(tmpl)
+		if err != nil {
+			t.Fatalf("unexpected error on Template: %v", err)
+		}
+		if err := r.Register(name, b); err != nil {
+			t.Fatalf("unexpected error on Register: %v", err)
+		}
+	}
+
+	name, vars, ok := r.Match("user:42:data:abc")
+	if !ok || name != "user_data" || vars["id"] != "42" || vars["dataID"] != "abc" {
+		t.Errorf("Match() = (%q, %v, %v), want the most specific template", name, vars, ok)
+	}
+
+	name, vars, ok = r.Match("session:s1")
+	if !ok || name != "session" || vars["id"] != "s1" {
+		t.Errorf("Match() = (%q, %v, %v)", name, vars, ok)
+	}
+
+	if _, _, ok := r.Match("order:1"); ok {
+		t.Error("expected no match")
+	}
+}
+
+func TestRegistry_TemplateFuncs(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterFunc("tenant", func(val string, _ ...string) (string, error) {
+		return "t-" + val, nil
+	})
+
+	if _, err := Template("foo:{bar|tenant}"); err == nil {
+		t.Fatal("expected an error for a function of another registry")
+	}
+
+	tmpl, err := Template("foo:{bar|tenant}", WithRegistry(r))
+	if err != nil {
+		t.Fatalf("unexpected error on Template: %v", err)
+	}
+	if err := r.Register("foo", tmpl); err != nil {
+		t.Fatalf("unexpected error on Register: %v", err)
+	}
+	key, err := tmpl.Build(nil, map[string]interface{}{"bar": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "foo:t-1" {
+		t.Errorf("got %q, want %q", key, "foo:t-1")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // KeyVars is an optional interface for custom structs to provide template variables.
@@ -20,112 +21,204 @@ type TransformFunc func(val string, args ...string) (string, error)
 // TemplateKeyBuilder builds keys from a template string with dynamic variables, transformations, and conditionals.
 type TemplateKeyBuilder struct {
 	Template string
-	funcs    map[string]TransformFunc
+
+	// mu guards funcs and prog, which RegisterFunc replaces while keys may be built.
+	mu     sync.RWMutex
+	funcs  map[string]TransformFunc
+	strict bool
+
+	// aliases are the function aliases the template was loaded with, see Registry.Load.
+	aliases map[string]string
+
+	// prog is the compiled template, built once by Template.
+	prog *program
+
+	// name and registry are set when the template is registered, and resolve its references to other templates.
+	name     string
+	registry *Registry
+
+	// flat caches the pattern and variables of templates with references, see matcher.
+	flatMu sync.Mutex
+	flat   *flatCache
 }
 
+// allowedRegex matches the characters allowed in templates.
+var allowedRegex = regexp.MustCompile(`^[a-zA-Z0-9/_|:.@\-{}(),\"' +]+$`)
+
 // Template creates a new TemplateKeyBuilder with built-in functions.
-func Template(tmpl string) (*TemplateKeyBuilder, error) {
+//
+// Returns an error if a placeholder uses a transformation function that is neither built-in
+// nor provided by the options (see WithFuncs and WithRegistry).
+func Template(tmpl string, opts ...TemplateOptions) (*TemplateKeyBuilder, error) {
 	if len(tmpl) == 0 {
 		return nil, fmt.Errorf("template string cannot be empty")
 	}
-	allowedRegex := regexp.MustCompile(`^[a-zA-Z0-9/_|:\-{}(),\"' +]+$`)
 	if !allowedRegex.MatchString(tmpl) {
-		return nil, fmt.Errorf("template contains invalid characters. Allowed: a-z, A-Z, 0-9, /, |, -, _, :, {}, (), ',', \" and space")
+		return nil, fmt.Errorf("template contains invalid characters. Allowed: a-z, A-Z, 0-9, /, |, -, _, :, ., @, {}, (), ',', \" and space")
+	}
+	if err := checkAmbiguity(tmpl); err != nil {
+		return nil, err
 	}
+
+	var o TemplateOption
+	for _, opt := range opts {
+		o = opt(o)
+	}
+
 	tb := &TemplateKeyBuilder{
 		Template: tmpl,
 		funcs:    make(map[string]TransformFunc),
+		strict:   o.Strict,
+		registry: o.Registry,
 	}
 	for k, v := range builtinFuncs {
 		tb.funcs[k] = v
 	}
+	if o.Registry != nil {
+		o.Registry.mu.RLock()
+		for k, v := range o.Registry.funcs {
+			tb.funcs[k] = v
+		}
+		o.Registry.mu.RUnlock()
+	}
+	for k, v := range o.Funcs {
+		tb.funcs[k] = v
+	}
+
+	prog, err := compile(tmpl, tb.funcs)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range prog.nodes {
+		for _, c := range n.calls {
+			if c.fn == nil {
+				return nil, fmt.Errorf("unknown transform function %q in placeholder of variable %q", c.name, n.name)
+			}
+		}
+	}
+	tb.prog = prog
+
 	return tb, nil
 }
 
 // RegisterFunc registers a custom transformation function for this template.
+// It is safe to call while keys are being built from the template.
 func (t *TemplateKeyBuilder) RegisterFunc(name string, fn TransformFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	t.funcs[name] = fn
+	if t.prog != nil {
+		t.prog = t.prog.resolve(name, fn)
+	}
 }
 
 // Build (Render) replaces {field|func1|func2(args)} in the template using data and applies transformations, defaults, and conditionals.
-func (t *TemplateKeyBuilder) Build(ctx context.Context, data any) (string, error) { //nolint:cyclop
-	vars := make(map[string]interface{})
-	switch v := data.(type) {
-	case KeyVars:
-		vars = v.KeyVars()
-	case map[string]interface{}:
-		vars = v
-	default:
-		rv := reflect.ValueOf(data)
-		if rv.Kind() == reflect.Ptr {
-			rv = rv.Elem()
-		}
-		if rv.Kind() == reflect.Struct {
-			rt := rv.Type()
-			for i := 0; i < rt.NumField(); i++ {
-				f := rt.Field(i)
-				if f.PkgPath == "" {
-					vars[f.Name] = rv.Field(i).Interface()
-				}
+//
+// Data can be a map, a key.KeyVars or a struct. Struct fields are bound under the name of their `kivigo` tag,
+// or their own name, and fields of embedded structs are promoted:
+//
+//	type User struct {
+//	    ID     int    `kivigo:"id,required"`
+//	    Region string `kivigo:"region,omitempty"`
+//	    Team   *Team  // {Team.Name} is the Name field of the team
+//	}
+//
+// With omitempty, an empty field is treated as missing, so that default() applies. With required,
+// Build returns an error if the field is empty.
+func (t *TemplateKeyBuilder) Build(ctx context.Context, data any) (string, error) {
+	if v, ok := data.(KeyVars); ok {
+		data = v.KeyVars()
+	}
+
+	var root reflect.Value
+	if _, ok := data.(map[string]interface{}); !ok {
+		root = reflect.ValueOf(data)
+	}
+
+	var b strings.Builder
+	if err := t.buildTo(&b, data, root, 0); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// buildTo writes the key built from data to b, building the referenced templates in place.
+func (t *TemplateKeyBuilder) buildTo(b *strings.Builder, data any, root reflect.Value, depth int) error {
+	prog, err := t.program()
+	if err != nil {
+		return err
+	}
+
+	if depth == 0 {
+		b.Grow(prog.size)
+	}
+	for i := range prog.nodes {
+		n := &prog.nodes[i]
+		switch {
+		case n.ref != "":
+			ref, err := t.resolveRef(n.ref, depth)
+			if err != nil {
+				return err
+			}
+			if err := ref.buildTo(b, data, root, depth+1); err != nil {
+				return err
+			}
+		case n.isVar:
+			val, err := t.eval(n, data, root)
+			if err != nil {
+				return err
 			}
+			b.WriteString(val)
+		default:
+			b.WriteString(n.literal)
 		}
 	}
+	return nil
+}
 
-	tmpl := t.Template
-	result := ""
-	start := 0
-	for {
-		op := strings.Index(tmpl[start:], "{")
-		if op == -1 {
-			result += tmpl[start:]
-			break
-		}
-		op += start
-		result += tmpl[start:op]
-		cl := strings.Index(tmpl[op:], "}")
-		if cl == -1 {
-			result += tmpl[op:]
-			break
-		}
-		cl += op
-		tok := tmpl[op+1 : cl]
-		val, err := t.evalToken(tok, vars)
-		if err != nil {
-			return "", err
+// Variable is a placeholder of a template.
+type Variable struct {
+	// Name is the name of the variable substituted in the placeholder.
+	Name string
+
+	// Transforms are the transform function calls applied to the variable, in order, e.g. "default('x')".
+	Transforms []string
+}
+
+// HasDefault reports whether a default transform is applied to the variable.
+func (v Variable) HasDefault() bool {
+	for _, fncall := range v.Transforms {
+		if fn, _ := parseFuncCall(fncall); fn == "default" {
+			return true
 		}
-		result += val
-		start = cl + 1
 	}
-	return result, nil
+	return false
 }
 
-// evalToken parses and evaluates a token like "field|upper|default('x')".
-func (t *TemplateKeyBuilder) evalToken(token string, vars map[string]interface{}) (string, error) {
-	parts := strings.Split(token, "|")
-	if len(parts) == 0 {
-		return "", nil
-	}
-	name := parts[0]
-	val, ok := vars[name]
-	var sval string
-	if ok {
-		sval = fmt.Sprintf("%v", val)
-	} else {
-		sval = ""
-	}
-	for _, fncall := range parts[1:] {
-		fn, args := parseFuncCall(fncall)
-		f, ok := t.funcs[fn]
-		if !ok {
-			return "", fmt.Errorf("unknown transform function: %s", fn)
+// Variables returns the placeholders of the template, in order of appearance, including those of
+// the templates it references. References that cannot be resolved are skipped.
+func (t *TemplateKeyBuilder) Variables() []Variable {
+	var vars []Variable
+	for _, fn := range t.flattenPartial() {
+		if fn.n.isVar {
+			vars = append(vars, Variable{Name: fn.n.name, Transforms: fn.n.transforms})
 		}
-		var err error
-		sval, err = f(sval, args...)
-		if err != nil {
-			return "", err
+	}
+	return vars
+}
+
+// StaticPrefix returns the part of the template before its first placeholder, including the literal
+// parts of the templates it references. Every key built from the template starts with this prefix.
+func (t *TemplateKeyBuilder) StaticPrefix() string {
+	var b strings.Builder
+	for _, fn := range t.flattenPartial() {
+		if fn.n.isVar || fn.n.ref != "" {
+			break
 		}
+		b.WriteString(fn.n.literal)
 	}
-	return sval, nil
+	return b.String()
 }
 
 // parseFuncCall parses "func(arg1,arg2)" or "func".
@@ -199,7 +292,10 @@ func parseArgs(s string) []string { //nolint:cyclop
 	return args
 }
 
-// Built-in transformation functions
+// Built-in transformation functions.
+//
+// Hashes (sha256, md5, fnv, crc32) are hex digests, truncated to the length given as argument, e.g. sha256(12).
+// base64 uses the unpadded URL-safe alphabet, and urlescape the query escaping of net/url.
 var builtinFuncs = map[string]TransformFunc{
 	"upper": func(val string, _ ...string) (string, error) {
 		return strings.ToUpper(val), nil
@@ -242,4 +338,18 @@ var builtinFuncs = map[string]TransformFunc{
 		}
 		return strconv.Itoa(v + delta), nil
 	},
+	"sha256":    sha256Func,
+	"md5":       md5Func,
+	"fnv":       fnvFunc,
+	"crc32":     crc32Func,
+	"pad":       padFunc,
+	"truncate":  truncateFunc,
+	"replace":   replaceFunc,
+	"urlescape": urlEscapeFunc,
+	"escape":    escapeFunc,
+	"date":      dateFunc,
+	"shard":     shardFunc,
+	"base64":    base64Func,
+	"hex":       hexFunc,
+	"uuid":      uuidFunc,
 }
@@ -2,8 +2,11 @@ package key
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/kivigo/kivigo/pkg/errs"
 )
 
 func TestTemplateKeyBuilder_Transformations(t *testing.T) {
@@ -35,17 +38,17 @@ func TestTemplateKeyBuilder_SlugifyAndIntAdd(t *testing.T) {
 }
 
 func TestTemplateKeyBuilder_CustomFunc(t *testing.T) {
-	tpl, err := Template("custom:{val|reverse}")
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	tpl.RegisterFunc("reverse", func(val string, _ ...string) (string, error) {
+	reverse := func(val string, _ ...string) (string, error) {
 		runes := []rune(val)
 		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
 			runes[i], runes[j] = runes[j], runes[i]
 		}
 		return string(runes), nil
-	})
+	}
+	tpl, err := Template("custom:{val|reverse}", WithFuncs(map[string]TransformFunc{"reverse": reverse}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	key, err := tpl.Build(context.Background(), map[string]interface{}{"val": "abcde"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -56,13 +59,47 @@ func TestTemplateKeyBuilder_CustomFunc(t *testing.T) {
 }
 
 func TestTemplateKeyBuilder_UnknownFunc(t *testing.T) {
-	tpl, err := Template("{foo|notafunc}")
+	_, err := Template("{foo|notafunc}")
+	if err == nil || !strings.Contains(err.Error(), "unknown transform function") {
+		t.Errorf("expected unknown transform function error, got %v", err)
+	}
+}
+
+func TestTemplateKeyBuilder_Strict(t *testing.T) {
+	tpl, err := Template("user:{userID}:data:{dataID}:{kind|default('raw')}", WithStrict())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	_, err = tpl.Build(context.Background(), map[string]interface{}{"foo": "bar"})
-	if err == nil || !strings.Contains(err.Error(), "unknown transform function") {
-		t.Errorf("expected unknown transform function error, got %v", err)
+
+	key, err := tpl.Build(context.Background(), map[string]interface{}{"userID": 1, "dataID": "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "user:1:data:a:raw" {
+		t.Errorf("got %q, want %q", key, "user:1:data:a:raw")
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"userID": 1},
+		{"userID": 1, "dataID": ""},
+		{"userID": 1, "dataID": nil},
+	} {
+		if _, err := tpl.Build(context.Background(), data); !errors.Is(err, errs.ErrMissingVariable) {
+			t.Errorf("Build(%v): expected ErrMissingVariable, got %v", data, err)
+		}
+	}
+
+	// Without strict mode, missing variables are substituted with an empty string
+	lax, err := Template("user:{userID}:data:{dataID}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err = lax.Build(context.Background(), map[string]interface{}{"userID": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key != "user:1:data:" {
+		t.Errorf("got %q, want %q", key, "user:1:data:")
 	}
 }
 
@@ -76,14 +113,17 @@ func TestTemplateKeyBuilder_FieldDelimiters(t *testing.T) {
 		{"foo:{foo-bar}", map[string]interface{}{"foo-bar": "A"}, "foo:A", false},
 		{"foo:{foo_bar}", map[string]interface{}{"foo_bar": "B"}, "foo:B", false},
 		{"foo:{foo/bar}", map[string]interface{}{"foo/bar": "C"}, "foo:C", false},
-		{"foo:{foo|bar}", map[string]interface{}{"foo|bar": "D"}, "", true}, // unknown function 'bar'
+		{"foo:{foo|bar}", map[string]interface{}{"foo|bar": "D"}, "", true}, // unknown function 'bar' rejected by Template
 		{"foo:{foo:bar}", map[string]interface{}{"foo:bar": "E"}, "foo:E", false},
 	}
 	for _, tc := range cases {
 		builder, err := Template(tc.template)
-		if err != nil {
+		if err != nil && !tc.wantErr {
 			t.Fatalf("unexpected error for template %q: %v", tc.template, err)
 		}
+		if err != nil {
+			continue
+		}
 		key, err := builder.Build(context.Background(), tc.data)
 		if tc.wantErr {
 			if err == nil {
@@ -254,3 +294,23 @@ func TestTemplateKeyBuilder_ReflectUnexported(t *testing.T) {
 		t.Errorf("got %q, want %q", key, "ok")
 	}
 }
+
+func TestTemplateKeyBuilder_VariablesAndStaticPrefix(t *testing.T) {
+	tpl, err := Template("user:{id|upper}:data:{dataID}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	vars := tpl.Variables()
+	if len(vars) != 2 || vars[0].Name != "id" || vars[1].Name != "dataID" {
+		t.Fatalf("unexpected variables: %+v", vars)
+	}
+	if len(vars[0].Transforms) != 1 || vars[0].Transforms[0] != "upper" || len(vars[1].Transforms) != 0 {
+		t.Errorf("unexpected transforms: %+v", vars)
+	}
+	if vars[0].HasDefault() || !(Variable{Name: "x", Transforms: []string{"upper", "default('y')"}}).HasDefault() {
+		t.Error("unexpected HasDefault result")
+	}
+	if got := tpl.StaticPrefix(); got != "user:" {
+		t.Errorf("got %q, want %q", got, "user:")
+	}
+}
@@ -2,22 +2,50 @@ package mock
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/kivigo/kivigo/pkg/errs"
 	"github.com/kivigo/kivigo/pkg/models"
 )
 
 var (
-	_ models.KV           = (*MockKV)(nil)
-	_ models.KVWithHealth = (*MockKV)(nil)
-	_ models.KVWithBatch  = (*MockKV)(nil)
+	_ models.KV              = (*MockKV)(nil)
+	_ models.KVWithHealth    = (*MockKV)(nil)
+	_ models.KVWithBatch     = (*MockKV)(nil)
+	_ models.KVWithTTL       = (*MockKV)(nil)
+	_ models.KVWithCAS       = (*MockKV)(nil)
+	_ models.KVWithTxn       = (*MockKV)(nil)
+	_ models.KVWithScan      = (*MockKV)(nil)
+	_ models.KVWithWatch     = (*MockKV)(nil)
+	_ models.KVWithKeyLimits = (*MockKV)(nil)
 )
 
 type MockKV struct { //nolint:revive
 	Data map[string][]byte
-	mu   sync.RWMutex
+
+	// Clock returns the current time used to evaluate expirations.
+	// If nil, time.Now is used. Override it to control expirations in tests.
+	Clock func() time.Time
+
+	// Limits are the key limits advertised by KeyLimits. The mock itself does not enforce them.
+	Limits models.KeyLimits
+
+	expiry   map[string]time.Time
+	versions map[string]uint64
+	revision uint64
+	watchers map[*watcher]struct{}
+	mu       sync.RWMutex
+}
+
+// watcher queues the events of a WatchRaw call so that writers are never blocked by slow readers.
+type watcher struct {
+	prefix string
+	mu     sync.Mutex
+	queue  []models.WatchEvent
+	signal chan struct{}
 }
 
 func (m *MockKV) GetRaw(_ context.Context, key string) ([]byte, error) {
@@ -27,9 +55,10 @@ func (m *MockKV) GetRaw(_ context.Context, key string) ([]byte, error) {
 
 	m.mu.RLock()
 	val, ok := m.Data[key]
+	expired := m.expired(key)
 	m.mu.RUnlock()
 
-	if !ok {
+	if !ok || expired {
 		return nil, errs.ErrNotFound
 	}
 
@@ -42,7 +71,7 @@ func (m *MockKV) SetRaw(_ context.Context, key string, value []byte) error {
 	}
 
 	m.mu.Lock()
-	m.Data[key] = value
+	m.write(key, value)
 	m.mu.Unlock()
 
 	return nil
@@ -53,13 +82,14 @@ func (m *MockKV) Delete(_ context.Context, key string) error {
 		return errs.ErrEmptyKey
 	}
 
-	if _, ok := m.Data[key]; !ok {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.Data[key]; !ok || m.expired(key) {
 		return errs.ErrNotFound
 	}
 
-	m.mu.Lock()
-	delete(m.Data, key)
-	m.mu.Unlock()
+	m.remove(key)
 
 	return nil
 }
@@ -69,7 +99,7 @@ func (m *MockKV) List(_ context.Context, prefix string) ([]string, error) {
 	var keys []string
 
 	for k := range m.Data {
-		if strings.HasPrefix(k, prefix) {
+		if strings.HasPrefix(k, prefix) && !m.expired(k) {
 			keys = append(keys, k)
 		}
 	}
@@ -93,7 +123,7 @@ func (m *MockKV) BatchGetRaw(_ context.Context, keys []string) (map[string][]byt
 	result := make(map[string][]byte)
 
 	for _, k := range keys {
-		if v, ok := m.Data[k]; ok {
+		if v, ok := m.Data[k]; ok && !m.expired(k) {
 			result[k] = v
 		} else {
 			m.mu.RUnlock()
@@ -110,7 +140,7 @@ func (m *MockKV) BatchGetRaw(_ context.Context, keys []string) (map[string][]byt
 func (m *MockKV) BatchSetRaw(_ context.Context, kv map[string][]byte) error {
 	m.mu.Lock()
 	for k, v := range kv {
-		m.Data[k] = v
+		m.write(k, v)
 	}
 	m.mu.Unlock()
 
@@ -126,13 +156,358 @@ func (m *MockKV) BatchDelete(_ context.Context, keys []string) error {
 
 	m.mu.Lock()
 	for _, k := range keys {
-		if _, ok := m.Data[k]; !ok {
+		if _, ok := m.Data[k]; !ok || m.expired(k) {
 			m.mu.Unlock()
 			return errs.ErrNotFound
 		}
-		delete(m.Data, k)
+		m.remove(k)
 	}
 	m.mu.Unlock()
 
 	return nil
 }
+
+// SetRawWithTTL implements models.KVWithTTL.
+// Sets the key-value pair and expires it once ttl has elapsed according to Clock.
+func (m *MockKV) SetRawWithTTL(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	m.mu.Lock()
+	m.write(key, value)
+	if ttl > 0 {
+		if m.expiry == nil {
+			m.expiry = make(map[string]time.Time)
+		}
+		m.expiry[key] = m.now().Add(ttl)
+	}
+	m.mu.Unlock()
+
+	return nil
+}
+
+// TTL implements models.KVWithTTL.
+// Returns the remaining time to live of the key, or zero if it has no expiration.
+func (m *MockKV) TTL(_ context.Context, key string) (time.Duration, error) {
+	if key == "" {
+		return 0, errs.ErrEmptyKey
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.Data[key]; !ok || m.expired(key) {
+		return 0, errs.ErrNotFound
+	}
+
+	expiresAt, ok := m.expiry[key]
+	if !ok {
+		return 0, nil
+	}
+
+	return expiresAt.Sub(m.now()), nil
+}
+
+// Persist implements models.KVWithTTL.
+// Removes the expiration of the key.
+func (m *MockKV) Persist(_ context.Context, key string) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.Data[key]; !ok || m.expired(key) {
+		return errs.ErrNotFound
+	}
+
+	delete(m.expiry, key)
+
+	return nil
+}
+
+// GetRawWithVersion implements models.KVWithCAS.
+// Returns the value of the key along with its version.
+func (m *MockKV) GetRawWithVersion(_ context.Context, key string) ([]byte, uint64, error) {
+	if key == "" {
+		return nil, 0, errs.ErrEmptyKey
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	version := m.version(key)
+	if version == 0 {
+		return nil, 0, errs.ErrNotFound
+	}
+
+	return m.Data[key], version, nil
+}
+
+// SetRawIfVersion implements models.KVWithCAS.
+// Sets the key-value pair only if the current version of the key matches version.
+func (m *MockKV) SetRawIfVersion(_ context.Context, key string, value []byte, version uint64) (uint64, error) {
+	if key == "" {
+		return 0, errs.ErrEmptyKey
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.version(key) != version {
+		return 0, errs.ErrConflict
+	}
+
+	return m.write(key, value), nil
+}
+
+// SetRawIfAbsent implements models.KVWithCAS.
+// Sets the key-value pair only if the key does not exist.
+func (m *MockKV) SetRawIfAbsent(ctx context.Context, key string, value []byte) (uint64, error) {
+	return m.SetRawIfVersion(ctx, key, value, 0)
+}
+
+// DeleteIfVersion implements models.KVWithCAS.
+// Deletes the key only if its current version matches version.
+func (m *MockKV) DeleteIfVersion(_ context.Context, key string, version uint64) error {
+	if key == "" {
+		return errs.ErrEmptyKey
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.version(key)
+	if current == 0 {
+		return errs.ErrNotFound
+	}
+
+	if current != version {
+		return errs.ErrConflict
+	}
+
+	m.remove(key)
+
+	return nil
+}
+
+// CommitTxn implements models.KVWithTxn.
+// Checks all conditions, then applies all operations under a single lock.
+func (m *MockKV) CommitTxn(_ context.Context, ops []models.TxnOp) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, op := range ops {
+		if op.Key == "" {
+			return errs.ErrEmptyKey
+		}
+
+		if op.Type == models.TxnOpCheckAbsent && m.version(op.Key) != 0 {
+			return errs.ErrConflict
+		}
+	}
+
+	for _, op := range ops {
+		switch op.Type {
+		case models.TxnOpSet:
+			m.write(op.Key, op.Value)
+		case models.TxnOpDelete:
+			m.remove(op.Key)
+		case models.TxnOpCheckAbsent:
+			// Already checked
+		}
+	}
+
+	return nil
+}
+
+// ScanRaw implements models.KVWithScan.
+// Returns up to opts.Limit entries (100 if zero) sorted by key. The cursor is the last key returned.
+func (m *MockKV) ScanRaw(_ context.Context, opts models.ScanOptions) (models.ScanPage, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]string, 0, len(m.Data))
+
+	for k := range m.Data {
+		switch {
+		case !strings.HasPrefix(k, opts.Prefix), m.expired(k):
+		case opts.Start != "" && k < opts.Start:
+		case opts.End != "" && k >= opts.End:
+		case opts.Cursor != "" && k <= opts.Cursor:
+		default:
+			keys = append(keys, k)
+		}
+	}
+
+	slices.Sort(keys)
+
+	page := models.ScanPage{}
+	if len(keys) > limit {
+		keys = keys[:limit]
+		page.Cursor = keys[limit-1]
+	}
+
+	page.Entries = make([]models.ScanEntry, 0, len(keys))
+	for _, k := range keys {
+		page.Entries = append(page.Entries, models.ScanEntry{Key: k, Value: m.Data[k]})
+	}
+
+	return page, nil
+}
+
+// WatchRaw implements models.KVWithWatch.
+// Reports every write made through any handle on this mock until ctx is done.
+func (m *MockKV) WatchRaw(ctx context.Context, prefix string) (<-chan models.WatchEvent, error) {
+	w := &watcher{
+		prefix: prefix,
+		signal: make(chan struct{}, 1),
+	}
+
+	m.mu.Lock()
+	if m.watchers == nil {
+		m.watchers = make(map[*watcher]struct{})
+	}
+	m.watchers[w] = struct{}{}
+	m.mu.Unlock()
+
+	ch := make(chan models.WatchEvent)
+
+	go func() {
+		defer close(ch)
+		defer func() {
+			m.mu.Lock()
+			delete(m.watchers, w)
+			m.mu.Unlock()
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.signal:
+			}
+
+			w.mu.Lock()
+			events := w.queue
+			w.queue = nil
+			w.mu.Unlock()
+
+			for _, evt := range events {
+				select {
+				case ch <- evt:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// write stores the value, clears any expiration and returns the new version of the key.
+// The caller must hold the write lock.
+func (m *MockKV) write(key string, value []byte) uint64 {
+	if m.versions == nil {
+		m.versions = make(map[string]uint64)
+	}
+
+	old, existed := m.Data[key]
+	if !existed || m.expired(key) {
+		old = nil
+	}
+
+	m.revision++
+	m.Data[key] = value
+	m.versions[key] = m.revision
+	delete(m.expiry, key)
+
+	m.notify(models.WatchEvent{Type: models.WatchEventPut, Key: key, OldValue: old, NewValue: value, Revision: m.revision})
+
+	return m.revision
+}
+
+// remove deletes the key along with its metadata.
+// The caller must hold the write lock.
+func (m *MockKV) remove(key string) {
+	old, existed := m.Data[key]
+
+	delete(m.Data, key)
+	delete(m.expiry, key)
+	delete(m.versions, key)
+
+	if existed {
+		m.revision++
+		m.notify(models.WatchEvent{Type: models.WatchEventDelete, Key: key, OldValue: old, Revision: m.revision})
+	}
+}
+
+// notify queues the event for every watcher whose prefix matches its key.
+// The caller must hold the write lock.
+func (m *MockKV) notify(evt models.WatchEvent) {
+	for w := range m.watchers {
+		if !strings.HasPrefix(evt.Key, w.prefix) {
+			continue
+		}
+
+		w.mu.Lock()
+		w.queue = append(w.queue, evt)
+		w.mu.Unlock()
+
+		select {
+		case w.signal <- struct{}{}:
+		default:
+			// A signal is already pending
+		}
+	}
+}
+
+// version returns the current version of the key, or zero if it does not exist.
+// Keys set directly through Data have no version until first read, so they are assigned one lazily.
+// The caller must hold the write lock.
+func (m *MockKV) version(key string) uint64 {
+	if _, ok := m.Data[key]; !ok || m.expired(key) {
+		return 0
+	}
+
+	if m.versions == nil {
+		m.versions = make(map[string]uint64)
+	}
+
+	if _, ok := m.versions[key]; !ok {
+		m.revision++
+		m.versions[key] = m.revision
+	}
+
+	return m.versions[key]
+}
+
+// now returns the current time according to Clock.
+func (m *MockKV) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock()
+	}
+
+	return time.Now()
+}
+
+// expired reports whether the key has an expiration in the past.
+// The caller must hold the lock.
+func (m *MockKV) expired(key string) bool {
+	expiresAt, ok := m.expiry[key]
+
+	return ok && !expiresAt.After(m.now())
+}
+
+// KeyLimits returns the limits of the Limits field.
+func (m *MockKV) KeyLimits() models.KeyLimits {
+	return m.Limits
+}
@@ -3,10 +3,12 @@ package mock
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/models"
 )
 
 // Test case types for better readability
@@ -519,3 +521,197 @@ func TestMockKV_ConcurrentAccess(t *testing.T) {
 	// Test should complete without race conditions
 	require.True(t, true)
 }
+
+func TestMockKV_TTL(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := newTestMockKV()
+	m.Clock = func() time.Time { return current }
+
+	ctx := context.Background()
+
+	require.NoError(t, m.SetRawWithTTL(ctx, "session", []byte("token"), time.Minute))
+	require.NoError(t, m.SetRaw(ctx, "config", []byte("value")))
+
+	ttl, err := m.TTL(ctx, "session")
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, ttl)
+
+	ttl, err = m.TTL(ctx, "config")
+	require.NoError(t, err)
+	require.Zero(t, ttl)
+
+	_, err = m.TTL(ctx, "missing")
+	require.ErrorIs(t, err, errs.ErrNotFound)
+
+	// Advance the clock past the expiration
+	current = current.Add(2 * time.Minute)
+
+	_, err = m.GetRaw(ctx, "session")
+	require.ErrorIs(t, err, errs.ErrNotFound)
+
+	keys, err := m.List(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"config"}, keys)
+
+	_, err = m.BatchGetRaw(ctx, []string{"session"})
+	require.ErrorIs(t, err, errs.ErrNotFound)
+
+	require.ErrorIs(t, m.Persist(ctx, "session"), errs.ErrNotFound)
+	require.ErrorIs(t, m.Delete(ctx, "session"), errs.ErrNotFound)
+}
+
+func TestMockKV_Persist(t *testing.T) {
+	current := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	m := newTestMockKV()
+	m.Clock = func() time.Time { return current }
+
+	ctx := context.Background()
+
+	require.NoError(t, m.SetRawWithTTL(ctx, "session", []byte("token"), time.Minute))
+	require.NoError(t, m.Persist(ctx, "session"))
+
+	current = current.Add(time.Hour)
+
+	val, err := m.GetRaw(ctx, "session")
+	require.NoError(t, err)
+	require.Equal(t, []byte("token"), val)
+
+	// SetRaw overrides a previous expiration
+	require.NoError(t, m.SetRawWithTTL(ctx, "session", []byte("token"), time.Minute))
+	require.NoError(t, m.SetRaw(ctx, "session", []byte("token2")))
+
+	ttl, err := m.TTL(ctx, "session")
+	require.NoError(t, err)
+	require.Zero(t, ttl)
+}
+
+func TestMockKV_CAS(t *testing.T) {
+	m := newTestMockKV()
+	m.Data["seeded"] = []byte("value")
+
+	ctx := context.Background()
+
+	// Keys seeded through Data are versioned on first access
+	val, seededVersion, err := m.GetRawWithVersion(ctx, "seeded")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), val)
+	require.NotZero(t, seededVersion)
+
+	_, err = m.SetRawIfAbsent(ctx, "seeded", []byte("other"))
+	require.ErrorIs(t, err, errs.ErrConflict)
+
+	version, err := m.SetRawIfAbsent(ctx, "key", []byte("v1"))
+	require.NoError(t, err)
+	require.NotZero(t, version)
+
+	newVersion, err := m.SetRawIfVersion(ctx, "key", []byte("v2"), version)
+	require.NoError(t, err)
+	require.Greater(t, newVersion, version)
+
+	_, err = m.SetRawIfVersion(ctx, "key", []byte("v3"), version)
+	require.ErrorIs(t, err, errs.ErrConflict)
+
+	// Any write bumps the version
+	require.NoError(t, m.SetRaw(ctx, "key", []byte("v4")))
+	require.ErrorIs(t, m.DeleteIfVersion(ctx, "key", newVersion), errs.ErrConflict)
+
+	_, current, err := m.GetRawWithVersion(ctx, "key")
+	require.NoError(t, err)
+	require.NoError(t, m.DeleteIfVersion(ctx, "key", current))
+
+	_, _, err = m.GetRawWithVersion(ctx, "key")
+	require.ErrorIs(t, err, errs.ErrNotFound)
+	require.ErrorIs(t, m.DeleteIfVersion(ctx, "key", current), errs.ErrNotFound)
+}
+
+func TestMockKV_CommitTxn(t *testing.T) {
+	m := newTestMockKV()
+	m.Data["existing"] = []byte("value")
+
+	ctx := context.Background()
+
+	err := m.CommitTxn(ctx, []models.TxnOp{
+		{Type: models.TxnOpSet, Key: "new", Value: []byte("value")},
+		{Type: models.TxnOpCheckAbsent, Key: "existing"},
+	})
+	require.ErrorIs(t, err, errs.ErrConflict)
+	require.NotContains(t, m.Data, "new")
+
+	err = m.CommitTxn(ctx, []models.TxnOp{
+		{Type: models.TxnOpCheckAbsent, Key: "missing"},
+		{Type: models.TxnOpSet, Key: "new", Value: []byte("value")},
+		{Type: models.TxnOpDelete, Key: "existing"},
+		{Type: models.TxnOpDelete, Key: "missing"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{"new": []byte("value")}, m.Data)
+
+	err = m.CommitTxn(ctx, []models.TxnOp{{Type: models.TxnOpSet, Key: ""}})
+	require.ErrorIs(t, err, errs.ErrEmptyKey)
+}
+
+func TestMockKV_ScanRaw(t *testing.T) {
+	m := newTestMockKV()
+	m.Data["a:1"] = []byte("1")
+	m.Data["a:2"] = []byte("2")
+	m.Data["a:3"] = []byte("3")
+	m.Data["b:1"] = []byte("4")
+
+	ctx := context.Background()
+
+	page, err := m.ScanRaw(ctx, models.ScanOptions{Prefix: "a:", Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []models.ScanEntry{{Key: "a:1", Value: []byte("1")}, {Key: "a:2", Value: []byte("2")}}, page.Entries)
+	require.Equal(t, "a:2", page.Cursor)
+
+	page, err = m.ScanRaw(ctx, models.ScanOptions{Prefix: "a:", Limit: 2, Cursor: page.Cursor})
+	require.NoError(t, err)
+	require.Equal(t, []models.ScanEntry{{Key: "a:3", Value: []byte("3")}}, page.Entries)
+	require.Empty(t, page.Cursor)
+
+	page, err = m.ScanRaw(ctx, models.ScanOptions{Start: "a:2", End: "b:1"})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	require.Equal(t, "a:2", page.Entries[0].Key)
+	require.Equal(t, "a:3", page.Entries[1].Key)
+}
+
+func TestMockKV_WatchRaw(t *testing.T) {
+	m := newTestMockKV()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := m.WatchRaw(ctx, "a:")
+	require.NoError(t, err)
+
+	require.NoError(t, m.SetRaw(ctx, "a:1", []byte("1")))
+	require.NoError(t, m.SetRaw(ctx, "b:1", []byte("ignored")))
+	require.NoError(t, m.BatchSetRaw(ctx, map[string][]byte{"a:1": []byte("2")}))
+	require.NoError(t, m.Delete(ctx, "a:1"))
+
+	want := []models.WatchEvent{
+		{Type: models.WatchEventPut, Key: "a:1", NewValue: []byte("1")},
+		{Type: models.WatchEventPut, Key: "a:1", OldValue: []byte("1"), NewValue: []byte("2")},
+		{Type: models.WatchEventDelete, Key: "a:1", OldValue: []byte("2")},
+	}
+
+	for _, w := range want {
+		select {
+		case evt := <-events:
+			require.NotZero(t, evt.Revision)
+			evt.Revision = 0
+			require.Equal(t, w, evt)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+	}
+
+	cancel()
+
+	for range events {
+		// Drain until closed
+	}
+}
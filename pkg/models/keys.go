@@ -0,0 +1,11 @@
+package models
+
+// KeyLimits are the restrictions of a backend on keys, advertised through KVWithKeyLimits.
+type KeyLimits struct {
+	// MaxLength is the maximum length of keys in bytes.
+	// If zero, the length of keys is not limited.
+	MaxLength int
+
+	// ForbiddenChars are the characters the backend does not accept in keys, e.g. " \t\r\n".
+	ForbiddenChars string
+}
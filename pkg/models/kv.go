@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"time"
 )
 
 // KV is the main interface for key-value backends.
@@ -109,4 +110,166 @@ type (
 		//   }
 		Health(ctx context.Context) error
 	}
+
+	KVWithTTL interface {
+		// SetRawWithTTL stores the given raw (encoded) value under the specified key
+		// and expires it once ttl has elapsed.
+		// Returns an error if the operation fails.
+		//
+		// Warning: Raw funcs are unsafe and any checks are performed before execution.
+		// Use normal funcs whenever possible or create directly your custom encoder/decoder.
+		//
+		// Example:
+		//   err := backend.SetRawWithTTL(ctx, "session:42", []byte("token"), 30*time.Minute)
+		//   if err != nil {
+		//       log.Fatal(err)
+		//   }
+		SetRawWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+		// TTL returns the remaining time to live of the specified key.
+		// Returns zero if the key has no expiration, or errs.ErrNotFound if the key does not exist.
+		//
+		// Example:
+		//   ttl, err := backend.TTL(ctx, "session:42")
+		//   if err != nil {
+		//       log.Fatal(err)
+		//   }
+		//   fmt.Println("Expires in:", ttl)
+		TTL(ctx context.Context, key string) (time.Duration, error)
+
+		// Persist removes the expiration of the specified key so it never expires.
+		// Returns errs.ErrNotFound if the key does not exist.
+		//
+		// Example:
+		//   err := backend.Persist(ctx, "session:42")
+		//   if err != nil {
+		//       log.Fatal(err)
+		//   }
+		Persist(ctx context.Context, key string) error
+	}
+
+	KVWithCAS interface {
+		// GetRawWithVersion retrieves the raw (encoded) value stored under the specified key along with its version.
+		// The version changes every time the key is written.
+		// Returns errs.ErrNotFound if the key does not exist.
+		//
+		// Warning: Raw funcs are unsafe and any checks are performed before execution.
+		// Use normal funcs whenever possible or create directly your custom encoder/decoder.
+		//
+		// Example:
+		//   raw, version, err := backend.GetRawWithVersion(ctx, "config")
+		//   if err != nil {
+		//       log.Fatal(err)
+		//   }
+		GetRawWithVersion(ctx context.Context, key string) (value []byte, version uint64, err error)
+
+		// SetRawIfVersion stores the given raw value only if the current version of the key matches version.
+		// A zero version means the key must not exist.
+		// Returns the new version, or errs.ErrConflict if the version does not match.
+		//
+		// Warning: Raw funcs are unsafe and any checks are performed before execution.
+		// Use normal funcs whenever possible or create directly your custom encoder/decoder.
+		//
+		// Example:
+		//   newVersion, err := backend.SetRawIfVersion(ctx, "config", []byte("v2"), version)
+		//   if errors.Is(err, errs.ErrConflict) {
+		//       // Someone else updated the key, reload and retry
+		//   }
+		SetRawIfVersion(ctx context.Context, key string, value []byte, version uint64) (newVersion uint64, err error)
+
+		// SetRawIfAbsent stores the given raw value only if the key does not exist.
+		// Returns the new version, or errs.ErrConflict if the key already exists.
+		//
+		// Warning: Raw funcs are unsafe and any checks are performed before execution.
+		// Use normal funcs whenever possible or create directly your custom encoder/decoder.
+		//
+		// Example:
+		//   version, err := backend.SetRawIfAbsent(ctx, "lock", []byte("owner"))
+		//   if errors.Is(err, errs.ErrConflict) {
+		//       // Lock already held
+		//   }
+		SetRawIfAbsent(ctx context.Context, key string, value []byte) (version uint64, err error)
+
+		// DeleteIfVersion removes the key only if its current version matches version.
+		// Returns errs.ErrConflict if the version does not match, or errs.ErrNotFound if the key does not exist.
+		//
+		// Example:
+		//   err := backend.DeleteIfVersion(ctx, "lock", version)
+		//   if err != nil {
+		//       log.Fatal(err)
+		//   }
+		DeleteIfVersion(ctx context.Context, key string, version uint64) error
+	}
+
+	KVWithTxn interface {
+		// CommitTxn applies the given operations atomically: either all of them are applied or none.
+		// Conditions such as TxnOpCheckAbsent are evaluated before any write is performed.
+		// Returns errs.ErrConflict if a condition does not hold.
+		//
+		// Warning: Raw funcs are unsafe and any checks are performed before execution.
+		// Use normal funcs whenever possible or create directly your custom encoder/decoder.
+		//
+		// Example:
+		//   err := backend.CommitTxn(ctx, []models.TxnOp{
+		//       {Type: models.TxnOpCheckAbsent, Key: "lock"},
+		//       {Type: models.TxnOpSet, Key: "foo", Value: []byte("1")},
+		//       {Type: models.TxnOpDelete, Key: "bar"},
+		//   })
+		//   if err != nil {
+		//       log.Fatal(err)
+		//   }
+		CommitTxn(ctx context.Context, ops []TxnOp) error
+	}
+
+	KVWithScan interface {
+		// ScanRaw returns a page of raw (encoded) entries matching the given options, sorted by key.
+		// Pass the returned cursor in the next call to retrieve the following page.
+		// Returns an error if the operation fails.
+		//
+		// Warning: Raw funcs are unsafe and any checks are performed before execution.
+		// Use normal funcs whenever possible or create directly your custom encoder/decoder.
+		//
+		// Example:
+		//   opts := models.ScanOptions{Prefix: "user:", Limit: 100}
+		//   for {
+		//       page, err := backend.ScanRaw(ctx, opts)
+		//       if err != nil {
+		//           log.Fatal(err)
+		//       }
+		//       for _, e := range page.Entries {
+		//           fmt.Printf("%s: %s\n", e.Key, string(e.Value))
+		//       }
+		//       if page.Cursor == "" {
+		//           break
+		//       }
+		//       opts.Cursor = page.Cursor
+		//   }
+		ScanRaw(ctx context.Context, opts ScanOptions) (ScanPage, error)
+	}
+
+	KVWithWatch interface {
+		// WatchRaw reports the changes made to keys starting with prefix, whoever made them.
+		// The returned channel is closed once ctx is done.
+		// Returns an error if the watch cannot be established.
+		//
+		// Example:
+		//   events, err := backend.WatchRaw(ctx, "config:")
+		//   if err != nil {
+		//       log.Fatal(err)
+		//   }
+		//   for evt := range events {
+		//       fmt.Printf("%s changed at revision %d\n", evt.Key, evt.Revision)
+		//   }
+		WatchRaw(ctx context.Context, prefix string) (<-chan WatchEvent, error)
+	}
+
+	KVWithKeyLimits interface {
+		// KeyLimits returns the restrictions of the backend on keys.
+		// Clients reject the keys exceeding them with errs.ErrInvalidKey before they reach the backend.
+		//
+		// Example:
+		//   limits := backend.KeyLimits()
+		//   fmt.Println("Max key length:", limits.MaxLength)
+		KeyLimits() KeyLimits
+	}
 )
@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -102,6 +103,125 @@ func TestKVWithBatchInterface(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestKVWithTTLInterface(t *testing.T) {
+	var kvTTL KVWithTTL
+
+	require.Nil(t, kvTTL)
+
+	mockKVTTL := &mockKVWithTTL{}
+	kvTTL = mockKVTTL
+	require.NotNil(t, kvTTL)
+
+	ctx := context.Background()
+
+	err := kvTTL.SetRawWithTTL(ctx, "key", []byte("value"), time.Minute)
+	require.NoError(t, err)
+
+	ttl, err := kvTTL.TTL(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, ttl)
+
+	err = kvTTL.Persist(ctx, "key")
+	require.NoError(t, err)
+
+	ttl, err = kvTTL.TTL(ctx, "key")
+	require.NoError(t, err)
+	require.Zero(t, ttl)
+}
+
+func TestKVWithCASInterface(t *testing.T) {
+	var kvCAS KVWithCAS
+
+	require.Nil(t, kvCAS)
+
+	mockKVCAS := &mockKVWithCAS{}
+	kvCAS = mockKVCAS
+	require.NotNil(t, kvCAS)
+
+	ctx := context.Background()
+
+	version, err := kvCAS.SetRawIfAbsent(ctx, "key", []byte("v1"))
+	require.NoError(t, err)
+
+	_, err = kvCAS.SetRawIfAbsent(ctx, "key", []byte("v1"))
+	require.ErrorIs(t, err, errs.ErrConflict)
+
+	newVersion, err := kvCAS.SetRawIfVersion(ctx, "key", []byte("v2"), version)
+	require.NoError(t, err)
+
+	value, current, err := kvCAS.GetRawWithVersion(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("v2"), value)
+	require.Equal(t, newVersion, current)
+
+	err = kvCAS.DeleteIfVersion(ctx, "key", version)
+	require.ErrorIs(t, err, errs.ErrConflict)
+
+	err = kvCAS.DeleteIfVersion(ctx, "key", current)
+	require.NoError(t, err)
+}
+
+func TestKVWithTxnInterface(t *testing.T) {
+	var kvTxn KVWithTxn
+
+	require.Nil(t, kvTxn)
+
+	mockKVTxn := &mockKVWithTxn{}
+	kvTxn = mockKVTxn
+	require.NotNil(t, kvTxn)
+
+	ops := []TxnOp{
+		{Type: TxnOpCheckAbsent, Key: "lock"},
+		{Type: TxnOpSet, Key: "foo", Value: []byte("1")},
+		{Type: TxnOpDelete, Key: "bar"},
+	}
+
+	err := kvTxn.CommitTxn(context.Background(), ops)
+	require.NoError(t, err)
+	require.Equal(t, ops, mockKVTxn.committed)
+}
+
+func TestKVWithScanInterface(t *testing.T) {
+	var kvScan KVWithScan
+
+	require.Nil(t, kvScan)
+
+	mockKVScan := &mockKVWithScan{keys: []string{"a", "b", "c"}}
+	kvScan = mockKVScan
+	require.NotNil(t, kvScan)
+
+	ctx := context.Background()
+
+	page, err := kvScan.ScanRaw(ctx, ScanOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, []ScanEntry{{Key: "a"}, {Key: "b"}}, page.Entries)
+	require.Equal(t, "b", page.Cursor)
+
+	page, err = kvScan.ScanRaw(ctx, ScanOptions{Limit: 2, Cursor: page.Cursor})
+	require.NoError(t, err)
+	require.Equal(t, []ScanEntry{{Key: "c"}}, page.Entries)
+	require.Empty(t, page.Cursor)
+}
+
+func TestKVWithWatchInterface(t *testing.T) {
+	var kvWatch KVWithWatch
+
+	require.Nil(t, kvWatch)
+
+	mockKVWatch := &mockKVWithWatch{}
+	kvWatch = mockKVWatch
+	require.NotNil(t, kvWatch)
+
+	events, err := kvWatch.WatchRaw(context.Background(), "key")
+	require.NoError(t, err)
+
+	evt := <-events
+	require.Equal(t, WatchEvent{Type: WatchEventPut, Key: "key", NewValue: []byte("value"), Revision: 1}, evt)
+
+	_, ok := <-events
+	require.False(t, ok)
+}
+
 func TestInterfaceComposition(t *testing.T) {
 	// Test that a struct can implement multiple interfaces
 	composite := &compositeKV{}
@@ -246,6 +366,121 @@ func (m *mockKVWithBatch) BatchDelete(_ context.Context, keys []string) error {
 	return nil
 }
 
+type mockKVWithTTL struct {
+	ttls map[string]time.Duration
+}
+
+func (m *mockKVWithTTL) SetRawWithTTL(_ context.Context, key string, _ []byte, ttl time.Duration) error {
+	if m.ttls == nil {
+		m.ttls = make(map[string]time.Duration)
+	}
+
+	m.ttls[key] = ttl
+
+	return nil
+}
+
+func (m *mockKVWithTTL) TTL(_ context.Context, key string) (time.Duration, error) {
+	ttl, ok := m.ttls[key]
+	if !ok {
+		return 0, errs.ErrNotFound
+	}
+
+	return ttl, nil
+}
+
+func (m *mockKVWithTTL) Persist(_ context.Context, key string) error {
+	if _, ok := m.ttls[key]; !ok {
+		return errs.ErrNotFound
+	}
+
+	m.ttls[key] = 0
+
+	return nil
+}
+
+type mockKVWithCAS struct {
+	value   []byte
+	version uint64
+}
+
+func (m *mockKVWithCAS) GetRawWithVersion(_ context.Context, _ string) ([]byte, uint64, error) {
+	if m.version == 0 {
+		return nil, 0, errs.ErrNotFound
+	}
+
+	return m.value, m.version, nil
+}
+
+func (m *mockKVWithCAS) SetRawIfVersion(_ context.Context, _ string, value []byte, version uint64) (uint64, error) {
+	if m.version != version {
+		return 0, errs.ErrConflict
+	}
+
+	m.value = value
+	m.version++
+
+	return m.version, nil
+}
+
+func (m *mockKVWithCAS) SetRawIfAbsent(ctx context.Context, key string, value []byte) (uint64, error) {
+	return m.SetRawIfVersion(ctx, key, value, 0)
+}
+
+func (m *mockKVWithCAS) DeleteIfVersion(_ context.Context, _ string, version uint64) error {
+	if m.version != version {
+		return errs.ErrConflict
+	}
+
+	m.value = nil
+	m.version = 0
+
+	return nil
+}
+
+type mockKVWithTxn struct {
+	committed []TxnOp
+}
+
+func (m *mockKVWithTxn) CommitTxn(_ context.Context, ops []TxnOp) error {
+	m.committed = append(m.committed, ops...)
+
+	return nil
+}
+
+type mockKVWithScan struct {
+	keys []string
+}
+
+func (m *mockKVWithScan) ScanRaw(_ context.Context, opts ScanOptions) (ScanPage, error) {
+	page := ScanPage{}
+
+	for _, k := range m.keys {
+		if k <= opts.Cursor {
+			continue
+		}
+
+		if len(page.Entries) == opts.Limit {
+			page.Cursor = page.Entries[len(page.Entries)-1].Key
+			break
+		}
+
+		page.Entries = append(page.Entries, ScanEntry{Key: k})
+	}
+
+	return page, nil
+}
+
+type mockKVWithWatch struct{}
+
+func (m *mockKVWithWatch) WatchRaw(_ context.Context, prefix string) (<-chan WatchEvent, error) {
+	ch := make(chan WatchEvent, 1)
+	ch <- WatchEvent{Type: WatchEventPut, Key: prefix, NewValue: []byte("value"), Revision: 1}
+	close(ch)
+
+	return ch, nil
+}
+
 type compositeKV struct {
 	data map[string][]byte
 }
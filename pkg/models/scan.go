@@ -0,0 +1,39 @@
+package models
+
+// ScanOptions configures a KVWithScan.ScanRaw call.
+type ScanOptions struct {
+	// Prefix restricts the scan to keys starting with this prefix.
+	Prefix string
+
+	// Cursor resumes the scan after the page that returned it.
+	// If empty, the scan starts from the beginning.
+	Cursor string
+
+	// Limit is the maximum number of entries returned in a page.
+	// If zero, the backend picks its own page size.
+	Limit int
+
+	// Start is the inclusive lower bound of the scanned keys.
+	// If empty, there is no lower bound.
+	Start string
+
+	// End is the exclusive upper bound of the scanned keys.
+	// If empty, there is no upper bound.
+	End string
+}
+
+// ScanEntry is a key-value pair returned by KVWithScan.ScanRaw.
+type ScanEntry struct {
+	Key   string
+	Value []byte
+}
+
+// ScanPage is a page of entries returned by KVWithScan.ScanRaw.
+type ScanPage struct {
+	// Entries are sorted by key in lexicographic order.
+	Entries []ScanEntry
+
+	// Cursor must be passed in ScanOptions to retrieve the next page.
+	// It is empty once the scan is complete.
+	Cursor string
+}
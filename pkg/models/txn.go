@@ -0,0 +1,20 @@
+package models
+
+// TxnOpType identifies the kind of an operation within a transaction.
+type TxnOpType int
+
+const (
+	// TxnOpSet stores Value under Key.
+	TxnOpSet TxnOpType = iota
+	// TxnOpDelete removes Key. Deleting a missing key is not an error.
+	TxnOpDelete
+	// TxnOpCheckAbsent requires Key not to exist when the transaction is committed.
+	TxnOpCheckAbsent
+)
+
+// TxnOp is a single operation of a transaction committed through KVWithTxn.
+type TxnOp struct {
+	Type  TxnOpType
+	Key   string
+	Value []byte
+}
@@ -0,0 +1,26 @@
+package models
+
+// WatchEventType identifies the kind of change reported by KVWithWatch.
+type WatchEventType int
+
+const (
+	// WatchEventPut is reported when a key is created or updated.
+	WatchEventPut WatchEventType = iota
+	// WatchEventDelete is reported when a key is deleted.
+	WatchEventDelete
+)
+
+// WatchEvent is a change reported by KVWithWatch.WatchRaw.
+type WatchEvent struct {
+	Type WatchEventType
+	Key  string
+
+	// OldValue is the raw value before the change, or nil if unknown or if the key did not exist.
+	OldValue []byte
+
+	// NewValue is the raw value after the change, or nil for deletions.
+	NewValue []byte
+
+	// Revision is a backend-specific, monotonically increasing identifier of the change.
+	Revision uint64
+}
@@ -0,0 +1,7 @@
+/*
+Package repository provides typed repositories binding key templates to a KiviGo client.
+
+A repository derives the key of each entity from its fields using a key template,
+so that entities can be saved, loaded, deleted and listed without building keys by hand.
+*/
+package repository
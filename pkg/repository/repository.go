@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/key"
+)
+
+// Repository stores entities of type T under keys built from a key template.
+type Repository[T any] struct {
+	typed client.Typed[T]
+	tmpl  *key.TemplateKeyBuilder
+}
+
+// New creates a repository storing entities of type T through the client under keys built from tmpl.
+//
+// Returns an error if a placeholder of the template is not bound to a field of T, as in key.TemplateKeyBuilder.Build.
+// The validation is skipped if T implements key.KeyVars or is a map, as their variables are only known at runtime.
+//
+// Example:
+//
+//	tmpl, _ := key.Template("user:{ID}")
+//	users, err := repository.New[User](client, tmpl)
+func New[T any](c client.Client, tmpl *key.TemplateKeyBuilder) (*Repository[T], error) {
+	if tmpl == nil {
+		return nil, fmt.Errorf("template cannot be nil")
+	}
+
+	if err := validate[T](tmpl); err != nil {
+		return nil, err
+	}
+
+	return &Repository[T]{typed: client.NewTyped[T](c), tmpl: tmpl}, nil
+}
+
+// FromRegistry creates a repository using the template registered under name in the registry.
+//
+// Example:
+//
+//	users, err := repository.FromRegistry[User](client, key.GlobalRegistry(), "user")
+func FromRegistry[T any](c client.Client, r *key.Registry, name string) (*Repository[T], error) {
+	tmpl, ok := r.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("template with name '%s' not found", name)
+	}
+
+	return New[T](c, tmpl)
+}
+
+// Key returns the key under which the entity is stored.
+func (r *Repository[T]) Key(ctx context.Context, entity T) (string, error) {
+	return r.tmpl.Build(ctx, entity)
+}
+
+// Save stores the entity under the key built from its fields.
+func (r *Repository[T]) Save(ctx context.Context, entity T, opts ...client.Options) error {
+	k, err := r.tmpl.Build(ctx, entity)
+	if err != nil {
+		return fmt.Errorf("failed to build key: %w", err)
+	}
+
+	return r.typed.Set(ctx, k, entity, opts...)
+}
+
+// Load retrieves the entity stored under the key built from vars, which can be a map, a struct or a key.KeyVars.
+// Returns errs.ErrNotFound if the entity does not exist.
+//
+// Example:
+//
+//	user, err := users.Load(ctx, map[string]any{"ID": 42})
+func (r *Repository[T]) Load(ctx context.Context, vars any, opts ...client.Options) (T, error) {
+	k, err := r.tmpl.Build(ctx, vars)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to build key: %w", err)
+	}
+
+	return r.typed.Get(ctx, k, opts...)
+}
+
+// Delete removes the entity stored under the key built from vars.
+func (r *Repository[T]) Delete(ctx context.Context, vars any, opts ...client.Options) error {
+	k, err := r.tmpl.Build(ctx, vars)
+	if err != nil {
+		return fmt.Errorf("failed to build key: %w", err)
+	}
+
+	return r.typed.Delete(ctx, k, opts...)
+}
+
+// ListAll returns the entities stored under keys built from the template, in key order.
+// Keys sharing the static prefix of the template but not matching it are skipped.
+func (r *Repository[T]) ListAll(ctx context.Context) ([]T, error) {
+	var entities []T
+
+	for entry, err := range r.typed.Client().Iterate(ctx, client.IterateOptions{Prefix: r.tmpl.StaticPrefix()}) {
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := r.tmpl.Parse(entry.Key); err != nil {
+			continue
+		}
+
+		var entity T
+		if err := entry.Decode(ctx, &entity); err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				// Deleted or expired since it was listed
+				continue
+			}
+			return nil, fmt.Errorf("failed to decode value for key %s: %w", entry.Key, err)
+		}
+
+		entities = append(entities, entity)
+	}
+
+	return entities, nil
+}
+
+// validate checks that every placeholder of the template is bound to a field of T.
+func validate[T any](tmpl *key.TemplateKeyBuilder) error {
+	rt := reflect.TypeFor[T]()
+	if rt.Implements(reflect.TypeFor[key.KeyVars]()) {
+		return nil
+	}
+
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	switch rt.Kind() {
+	case reflect.Map:
+		return nil
+	case reflect.Struct:
+	default:
+		return fmt.Errorf("entity type %s must be a struct, a map or implement key.KeyVars", rt)
+	}
+
+	if err := tmpl.ValidateType(rt); err != nil {
+		return fmt.Errorf("template %q cannot be built from %s: %w", tmpl.Template, rt, err)
+	}
+
+	return nil
+}
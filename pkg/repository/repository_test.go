@@ -0,0 +1,158 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kivigo/encoders/json"
+
+	"github.com/kivigo/kivigo/pkg/client"
+	"github.com/kivigo/kivigo/pkg/errs"
+	"github.com/kivigo/kivigo/pkg/key"
+	"github.com/kivigo/kivigo/pkg/mock"
+	"github.com/kivigo/kivigo/pkg/repository"
+)
+
+type User struct {
+	ID   int
+	Name string
+}
+
+func newClient(t *testing.T) (client.Client, *mock.MockKV) {
+	t.Helper()
+
+	mockKV := &mock.MockKV{Data: map[string][]byte{}}
+
+	c, err := client.New(mockKV, client.Option{Encoder: json.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, mockKV
+}
+
+func TestRepository(t *testing.T) {
+	c, mockKV := newClient(t)
+	ctx := context.Background()
+
+	tmpl, err := key.Template("user:{ID}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := repository.New[User](c, tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []User{{ID: 1, Name: "alice"}, {ID: 2, Name: "bob"}} {
+		if err := users.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, ok := mockKV.Data["user:1"]; !ok {
+		t.Errorf("expected the key to be derived from the entity, got %v", mockKV.Data)
+	}
+
+	got, err := users.Load(ctx, map[string]any{"ID": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "bob" {
+		t.Errorf("Load() = %+v", got)
+	}
+
+	all, err := users.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].Name != "alice" || all[1].Name != "bob" {
+		t.Errorf("ListAll() = %+v", all)
+	}
+
+	if err := users.Delete(ctx, User{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Load(ctx, User{ID: 1}); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestRepository_ListAllSkipsOtherTemplates(t *testing.T) {
+	c, mockKV := newClient(t)
+	ctx := context.Background()
+
+	tmpl, err := key.Template("user:{ID}:profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := repository.New[User](c, tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.Save(ctx, User{ID: 1, Name: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stored under the same static prefix by another template
+	mockKV.Data["user:1:avatar"] = []byte("binary")
+
+	all, err := users.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].Name != "alice" {
+		t.Errorf("ListAll() = %+v", all)
+	}
+}
+
+func TestRepository_FromRegistry(t *testing.T) {
+	c, _ := newClient(t)
+
+	r := key.NewRegistry()
+
+	tmpl, err := key.Template("user:{ID}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("user", tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repository.FromRegistry[User](c, r, "user"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repository.FromRegistry[User](c, r, "missing"); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
+
+func TestRepository_Validation(t *testing.T) {
+	c, _ := newClient(t)
+
+	tmpl, err := key.Template("user:{ID}:{Email|default('none')}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repository.New[User](c, tmpl); err == nil {
+		t.Error("expected an error for a placeholder that is not a field of the entity")
+	}
+
+	if _, err := repository.New[*User](c, tmpl); err == nil {
+		t.Error("expected an error for a pointer entity as well")
+	}
+
+	if _, err := repository.New[map[string]any](c, tmpl); err != nil {
+		t.Errorf("expected maps to skip the validation, got %v", err)
+	}
+
+	if _, err := repository.New[string](c, tmpl); err == nil {
+		t.Error("expected an error for a non-struct entity")
+	}
+}